@@ -0,0 +1,87 @@
+package cronjobs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// chain triggers the jobs to run after j, once its run is over:
+// when it failed, they are skipped with ErrPredecessorFailed,
+// and so are the jobs to run after them.
+func (s *Scheduler) chain(j *job, run *Run) {
+	for _, next := range s.dependents(j.name) {
+		if run.Error != nil {
+			skipped := &Run{
+				ID:        s.runID.Add(1),
+				Name:      next.name,
+				Error:     fmt.Errorf("%w: %s", ErrPredecessorFailed, j.name),
+				StartedAt: s.clock.Now(),
+				final:     true,
+			}
+			s.emit(next, skipped)
+			s.chain(next, skipped)
+			continue
+		}
+		// registered before the goroutine, as the run of j is not over yet
+		if !s.begin() {
+			return
+		}
+		go func(next *job) {
+			defer s.inflight.Done()
+			s.run(next)
+		}(next)
+	}
+}
+
+// dependents returns the enabled jobs to run after the job with the given name
+func (s *Scheduler) dependents(name string) []*job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var jobs []*job
+	for _, j := range s.jobs {
+		if j.after == name && j.enabled {
+			jobs = append(jobs, j)
+		}
+	}
+	return jobs
+}
+
+// checkPredecessors warns about the jobs to run after a job which is not
+// registered, and would never run. s.mu must be held.
+func (s *Scheduler) checkPredecessors() {
+	for name, j := range s.jobs {
+		if j.after == "" {
+			continue
+		}
+		if _, ok := s.jobs[j.after]; !ok {
+			s.warn("job to run after an unknown job", "job", name, "after", j.after)
+		}
+	}
+}
+
+// checkCycles fails if registering jobs, replacing the ones of the file
+// replace, would make a job run after itself. s.mu must be held.
+func (s *Scheduler) checkCycles(jobs []*job, replace string) error {
+	after := make(map[string]string)
+	for name, j := range s.jobs {
+		if replace == "" || j.path != replace {
+			after[name] = j.after
+		}
+	}
+	for _, j := range jobs {
+		after[j.name] = j.after
+	}
+	for _, j := range jobs {
+		path := []string{j.name}
+		for next := after[j.name]; next != ""; next = after[next] {
+			path = append(path, next)
+			if next == j.name {
+				return fmt.Errorf("dependency cycle: %s", strings.Join(path, " after "))
+			}
+			if len(path) > len(after) {
+				break // cycle not involving j, which can't be
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package cronjobs
+
+import (
+	"sort"
+	"time"
+)
+
+// Clock is the source of time of the scheduler, for the run times and
+// durations, the delays between retries and of jitter, and the
+// maintenance windows. It can be replaced by a fake clock in tests,
+// with WithClock: the cron schedules themselves follow the wall clock,
+// and Tick fires the jobs on the schedules of the fake clock.
+type Clock interface {
+	Now() time.Time
+	// After returns a channel receiving the time once d elapsed
+	After(d time.Duration) <-chan time.Time
+}
+
+// wallClock is the default Clock, from the time package
+type wallClock struct{}
+
+func (wallClock) Now() time.Time                         { return time.Now() }
+func (wallClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Tick fires the enabled jobs due on their spec since the previous Tick,
+// or since Start, at the time of the clock: with a fake clock, advancing
+// it then calling Tick fires the jobs as the cron schedules would at that
+// time, each job once at most. The jobs are fired one after the other,
+// in name order, and Tick returns once they are over.
+// It fails with ErrNotStarted before Start, and ErrStopped after Stop.
+func (s *Scheduler) Tick() error {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return ErrStopped
+	}
+	if !s.started {
+		s.mu.Unlock()
+		return ErrNotStarted
+	}
+	now := s.clock.Now()
+	var due []*job
+	for _, j := range s.jobs {
+		if j.enabled && j.schedule != nil && !j.schedule.Next(s.ticked.In(s.location)).After(now) {
+			due = append(due, j)
+		}
+	}
+	s.ticked = now
+	s.mu.Unlock()
+
+	sort.Slice(due, func(a, b int) bool { return due[a].name < due[b].name })
+	for _, j := range due {
+		s.fire(j)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package cronjobs_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/db-journey/cronjobs"
+	"github.com/db-journey/cronjobs/cronjobstest"
+)
+
+// fakeClock is a cronjobs.Clock whose time only changes with Advance.
+// Its delays elapse right away.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- c.Now().Add(d)
+	return ch
+}
+
+// Advance moves the time forward by d
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestTick(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC))
+	d := &cronjobstest.FakeDriver{}
+	s := newScheduler(t, d, cronjobs.WithClock(clock), cronjobs.WithLocation(time.UTC))
+	if err := s.AddReader("report", "0 9 * * *", strings.NewReader("SELECT 1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Tick(); !errors.Is(err, cronjobs.ErrNotStarted) {
+		t.Errorf("Tick before Start = %v, want ErrNotStarted", err)
+	}
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		advance time.Duration
+		runs    int
+	}{
+		{30 * time.Minute, 0}, // 08:30
+		{30 * time.Minute, 1}, // 09:00
+		{0, 1},                // 09:00 again
+		{23 * time.Hour, 1},   // 08:00 the next day
+		{2 * time.Hour, 2},    // 10:00 the next day
+	}
+	for _, step := range steps {
+		clock.Advance(step.advance)
+		if err := s.Tick(); err != nil {
+			t.Fatal(err)
+		}
+		if n := d.Count(); n != step.runs {
+			t.Fatalf("at %v: %d runs, want %d", clock.Now(), n, step.runs)
+		}
+	}
+	run, _ := s.LastRun("report")
+	if want := clock.Now(); !run.StartedAt.Equal(want) {
+		t.Errorf("last run started at %v, want %v", run.StartedAt, want)
+	}
+}
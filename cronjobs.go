@@ -1,112 +1,410 @@
 // Package cronjobs provides a way to schedule DB jobs, using cron specs.
 // see https://godoc.org/github.com/robfig/cron for more info on cron spec format.
 // The package relies (for now) on files located in a folder passed as an argument to ReadFiles.
-// The files can have any extention, and must contain a first line with the cron spec: "[...]cron: [spec]"
+// The files can have any extention, and must contain a line with the cron spec: "[...]cron: [spec]"
 // ex: "-- cron: @daily" for sql
 // The line can start with any comment chars, and must end with the spec.
+// The spec is looked for on the first line, then on the following comment lines (see StrictFirstLine).
+// Several specs can be given on consecutive lines: the job then runs on each of them,
+// named after the file with a "#1", "#2"... suffix in the order of the lines.
+// The spec can be prefixed with a time zone: "-- cron: CRON_TZ=America/New_York 0 9 * * *",
+// otherwise it runs in the scheduler location (see WithLocation).
+// Besides the descriptors of robfig/cron, like "@every 1h30m",
+// the "@reboot" spec runs the job once, when the scheduler starts.
+//
+// # Metadata
+//
+// The spec can be followed by key=value metadata, the value double-quoted if needed:
+//
+//	-- cron: @daily name=nightly-rollup desc="refresh sales" tags=finance,reporting
+//
+// The keys are:
+//   - name overrides the name derived from the file name.
+//   - desc and tags describe the job.
+//   - enabled=false keeps the job from being scheduled, while still listed.
+//   - runAtStart=true also runs the job once when the scheduler starts.
+//   - logLevel sets the slog level of the successful runs with WithLogger, ex: logLevel=debug.
+//   - after=<job> runs the job after each successful run of another job, see below.
+//   - timeout overrides the WithJobTimeout timeout, ex: timeout=10m.
+//   - priority=low, normal, high, or an integer, orders the jobs waiting for a WithMaxConcurrency slot.
+//   - minInterval skips the runs, scheduled or triggered, starting less than that after the previous one, with ErrThrottled, ex: minInterval=5m.
+//   - jitter overrides the WithJitter delay, ex: jitter=30s or jitter=0.
+//   - driver selects a driver added with WithDriver.
+//
+// Unknown keys are ignored.
+// A job with after= can leave out its spec: "-- cron: after=ingest". It is
+// skipped with ErrPredecessorFailed when the other job fails, and a warning
+// is logged by Start and Reload if the other job is unknown.
+//
+// Instead of spec lines, a file can start with a JSON front-matter block, holding
+// the spec and the metadata:
+//
+//	/* cronjobs: {"spec": "@daily", "tags": ["finance"]} */
 package cronjobs
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"path"
-	"path/filepath"
-	"regexp"
+	"log/slog"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/db-journey/migrate/v2/driver"
 	"github.com/robfig/cron/v3"
 )
 
-type scheduler struct {
+// Scheduler runs DB jobs read from files on their cron schedule
+type Scheduler struct {
 	*cron.Cron
 	driver driver.Driver
 	runs   chan *Run
-	Logger func(chan *Run) // This function will just output a simple status on stdout, and can be overwritten
+	Logger RunLogger // The default StdoutLogger will just output a simple status on stdout, and can be overwritten
+
+	// Recursive makes ReadFiles load files found in subdirectories too.
+	Recursive bool
+	// Pattern, when set, is a glob (ex: "*.sql") matched against file names:
+	// ReadFiles ignores files that don't match it.
+	Pattern string
+	// SkipUnmatched makes ReadFiles ignore files without a cron spec line,
+	// instead of failing. Files with an invalid spec still fail.
+	SkipUnmatched bool
+	// StrictFirstLine makes ReadFiles only look for the cron spec on the
+	// first line of files, instead of the first comment line holding one.
+	StrictFirstLine bool
+	// CommentPrefixes, when set, are the only prefixes spec lines can start
+	// with, after blanks, before "cron:": ex: []string{"--", "#", "//"}.
+	// By default, the first line can start with anything.
+	CommentPrefixes []string
+
+	sem            *slots
+	maxConcurrency int
+	sequential     bool
+	retry          retry
+	jobTimeout     time.Duration
+	hardTimeout    time.Duration
+	jitter         time.Duration
+	bufferSize     int
+	fullPolicy     FullPolicy
+	dropped        atomic.Uint64
+	runID          atomic.Uint64
+	paused         atomic.Bool
+	executing      atomic.Int64
+	observers      []func(*Run)
+	eventObservers []func(Event)
+	cronOpts       []cron.Option
+	location       *time.Location
+	clock          Clock
+	windows        []window
+	seconds        bool
+	parser         cron.Parser
+	stopOnce       sync.Once
+	inflight       sync.WaitGroup
+	stopping       chan struct{}
+	stopCtx        context.Context // cancelled once stopping, for the jobs waiting for a slot
+	cancelStop     context.CancelFunc
+	runCtx         context.Context // parent of the job contexts, cancelled by StopWait
+	cancelRuns     context.CancelFunc
+	done           chan struct{}
+	drained        chan struct{}
+
+	hooks        hooks
+	slog         *slog.Logger
+	persistence  *persistence
+	catchUp      bool
+	locks        bool
+	drivers      map[string]driver.Driver
+	overrides    map[string]string
+	strict       bool
+	redactor     func(string) string
+	dupBodies    bool
+	requireJobs  bool
+	nameFunc     func(path string) string // nil for jobName
+	ordered      bool
+	templating   *templating
+	envExpansion envExpansion
+	split        bool
+	tx           bool
+	dryRun       bool
+	history      history
+	stats        stats
+
+	mu          sync.Mutex
+	started     bool
+	stopped     bool
+	ticked      time.Time // time of the last Tick, or of Start
+	jobs        map[string]*job
+	groups      map[string]*specGroup // by spec, with WithOrdered
+	subscribers []subscriber
+	events      []Event // queued for the eventObservers
+	closed      bool
 }
 
-// New creates a new cron scheduler
-func New(driver driver.Driver) *scheduler {
-	return &scheduler{
-		cron.New(),
-		driver,
-		make(chan *Run, 128),
-		logger,
+// New creates a new cron scheduler, running the jobs on driver.
+// It panics if driver is nil, rather than at the first run, and if the
+// options conflict.
+func New(driver driver.Driver, opts ...Option) *Scheduler {
+	if driver == nil {
+		panic("cronjobs: New called with a nil driver")
+	}
+	s := &Scheduler{
+		driver:     driver,
+		bufferSize: 128,
+		jobs:       make(map[string]*job),
+		Logger:     StdoutLogger{},
+		stopping:   make(chan struct{}),
+		done:       make(chan struct{}),
+		drained:    make(chan struct{}),
+		history:    history{size: 10},
+		location:   time.Local,
+		clock:      wallClock{},
+	}
+	s.runCtx, s.cancelRuns = context.WithCancel(context.Background())
+	s.stopCtx, s.cancelStop = context.WithCancel(s.runCtx)
+	for _, opt := range opts {
+		opt(s)
 	}
+	switch {
+	case s.sequential && s.maxConcurrency > 0:
+		panic("cronjobs: New called with both WithSequential and WithMaxConcurrency")
+	case s.sequential:
+		s.sem = newSlots(1)
+		s.sem.fifo = true
+	case s.maxConcurrency > 0:
+		s.sem = newSlots(s.maxConcurrency)
+	}
+	if p := s.persistence; p != nil && !s.dryRun {
+		s.hooks.onRun = append(s.hooks.onRun, func(run *Run) { p.insert(s, run) })
+	}
+	fields := cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor
+	if s.seconds {
+		fields |= cron.Second
+	}
+	s.parser = cron.NewParser(fields)
+	s.Cron = cron.New(append(s.cronOpts, cron.WithParser(s.parser))...)
+	s.runs = make(chan *Run, s.bufferSize)
+	return s
 }
 
 // Run defines an entry that will be created from each job for logging
 type Run struct {
+	// ID identifies the firing of the job the run belongs to,
+	// shared by its retries: it is unique for the scheduler.
+	ID       uint64
 	Name     string
 	Error    error
 	Duration time.Duration
+	Attempt  int // 1 for the first run, incremented on each retry
+	// StartedAt is the time the run started
+	StartedAt time.Time
+	// RowsAffected is the number of rows affected by the job statements,
+	// nil unless the driver is a ResultExecutor.
+	RowsAffected *int64
+	// DryRun is set when the job was not executed, with WithDryRun
+	DryRun bool
+
+	logLevel *slog.Level // level of the job, from its logLevel= metadata
+	final    bool        // last Run of its firing, not followed by a retry
+}
+
+// Failed reports whether the run failed: it has an error, which is not
+// the one of a skipped run, see ErrSkipped.
+func (r *Run) Failed() bool {
+	return r.Error != nil && !errors.Is(r.Error, ErrSkipped)
 }
 
-var cronRE = regexp.MustCompile(`^.*cron:\s+(.*)\n`)
+// namedDrivers returns the drivers added by WithDriver
+func (s *Scheduler) namedDrivers() []driver.Driver {
+	drivers := make([]driver.Driver, 0, len(s.drivers))
+	for _, d := range s.drivers {
+		drivers = append(drivers, d)
+	}
+	return drivers
+}
 
-// ReadFiles will scan files and return a list of Jobs
-// the driver is attached to each Job to implement the cron.Job interface
-func (s *scheduler) ReadFiles(dirname string) error {
+// specFormat describes the spec format expected by the scheduler
+func (s *Scheduler) specFormat() string {
+	if s.seconds {
+		return "6 fields, with seconds"
+	}
+	return "5 fields"
+}
 
-	// find all cronjobs files in path.
-	ioFiles, err := ioutil.ReadDir(dirname)
-	if err != nil {
-		return err
+// Start will start the cron jobs.
+// When ctx is cancelled, the scheduler is stopped as if Stop was called.
+// The enabled jobs with the @reboot spec, or runAtStart=true, are run once,
+// right away, as scheduled runs are, and so are the jobs which missed a run
+// with WithCatchUp.
+// A scheduler can only be started once: Start fails with ErrStarted when
+// called again, and with ErrStopped once the scheduler is stopped,
+// a new scheduler being needed to start over.
+// Starting without jobs is logged, or fails with ErrNoJobs with WithRequireJobs.
+func (s *Scheduler) Start(ctx context.Context) error {
+	defer s.flushEvents()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return ErrStopped
+	}
+	if s.started {
+		return ErrStarted
 	}
-	for _, f := range ioFiles {
-		fPath := path.Join(dirname, f.Name())
-		data, err := ioutil.ReadFile(fPath)
-		if err != nil {
-			return err
+	if len(s.jobs) == 0 {
+		if s.requireJobs {
+			return ErrNoJobs
 		}
-
-		content := string(data)
-		match := cronRE.FindStringSubmatch(content)
-		if len(match) < 2 {
-			err := fmt.Errorf(`File %s: Cron spec ("[...]cron: [spec]") was not found`, fPath)
-			return err
+		s.warn("starting without jobs")
+	}
+	s.checkPredecessors()
+	if s.locks {
+		for _, d := range append([]driver.Driver{s.driver}, s.namedDrivers()...) {
+			if _, ok := d.(JobLocker); !ok {
+				return fmt.Errorf("job locks: driver %T is not a JobLocker", d)
+			}
 		}
-		spec := match[1]
-		jobName := strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))
-
-		runFunc := func() {
-			start := time.Now()
-			err := s.driver.Execute(content)
-			s.runs <- &Run{
-				Name:     jobName,
-				Error:    err,
-				Duration: time.Since(start),
+	}
+	if s.tx {
+		for _, d := range append([]driver.Driver{s.driver}, s.namedDrivers()...) {
+			if _, ok := d.(TxExecutor); !ok {
+				s.warn("transactions are not supported by the driver, jobs run without them", "driver", fmt.Sprintf("%T", d))
 			}
 		}
-		if _, err := s.AddFunc(spec, runFunc); err != nil {
-			return fmt.Errorf(`File %s: %s`, fPath, err)
+	}
+	if s.persistence != nil && !s.dryRun {
+		if err := s.persistence.bootstrap(s); err != nil {
+			return fmt.Errorf("creating the runs table: %w", err)
+		}
+	}
+	var missed []*job
+	if s.catchUp {
+		if s.persistence == nil {
+			return errors.New("catch up: run persistence is required")
+		}
+		q, ok := s.driver.(TimeQuerier)
+		if !ok {
+			return fmt.Errorf("catch up: driver %T is not a TimeQuerier", s.driver)
+		}
+		if !s.dryRun {
+			missed = s.persistence.missed(s, q, s.clock.Now())
 		}
 	}
+	s.started = true
+	s.ticked = s.clock.Now()
+	s.queueEvent(Started, "")
 
+	go s.dispatch()
+	s.Cron.Start()
+	for _, j := range s.jobs {
+		if j.enabled && j.atStart {
+			go s.run(j)
+		}
+	}
+	for _, j := range missed {
+		go s.run(j)
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Stop()
+		case <-s.done:
+		}
+	}()
 	return nil
 }
 
-// Start will start the cron jobs
-func (s *scheduler) Start() {
-	go s.Logger(s.runs)
-	s.Cron.Start()
+// Stop stops the cron jobs.
+// No new runs are started, the jobs waiting for a WithMaxConcurrency slot
+// being skipped with ErrSkippedStopping, and Stop waits for in-flight jobs to finish,
+// and for their OnSuccess and OnFailure callbacks,
+// before closing the runs channel, which closes the Runs channels.
+// It is safe to call Stop more than once, or after the Start context was cancelled.
+func (s *Scheduler) Stop() {
+	s.StopWait(context.Background())
 }
 
-// Stop stops the cron jobs
-func (s *scheduler) Stop() {
-	s.Cron.Stop()
-	close(s.runs)
+// StopWait is like Stop, but only waits for in-flight jobs until ctx is done:
+// the contexts of the jobs still running are then cancelled, and StopWait
+// returns an error wrapping ctx.Err() and listing them. The runs channel is
+// closed once the jobs are over, which only depends on the drivers for the
+// jobs which can't be cancelled, see ContextExecutor.
+func (s *Scheduler) StopWait(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		s.mu.Lock()
+		s.stopped = true
+		started := s.started
+		s.mu.Unlock()
+		close(s.stopping)
+		s.cancelStop()
+		go func() {
+			<-s.Cron.Stop().Done()
+			s.inflight.Wait()
+			s.hooks.wg.Wait()
+			s.cancelRuns()
+			close(s.runs)
+			if !started {
+				// no dispatch to close them
+				s.closeSubscribers()
+			}
+			close(s.done)
+			s.notify(Stopped, "")
+		}()
+	})
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		s.cancelRuns()
+		if running := s.runningJobs(); len(running) > 0 {
+			return fmt.Errorf("%w: jobs still running: %s", ctx.Err(), strings.Join(running, ", "))
+		}
+		return ctx.Err()
+	}
 }
 
-// TODO: add context for cancelling
-var logger = func(runs chan *Run) {
-	for run := range runs {
-		fmt.Printf("Running %s: ", run.Name)
-		if run.Error != nil {
-			fmt.Printf("error=%s\n", run.Error)
-		} else {
-			fmt.Printf("OK\n")
+// runningJobs returns the names of the jobs running, sorted
+func (s *Scheduler) runningJobs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var names []string
+	for name, j := range s.jobs {
+		if j.running.Load() {
+			names = append(names, name)
 		}
 	}
+	sort.Strings(names)
+	return names
+}
+
+// Wait blocks until the scheduler is stopped, by Stop or by the
+// cancellation of the Start context, and all runs were logged.
+func (s *Scheduler) Wait() {
+	<-s.done
+	s.mu.Lock()
+	started := s.started
+	s.mu.Unlock()
+	if started {
+		<-s.drained
+	}
+}
+
+// Pause skips the scheduled runs of all the jobs, with ErrPaused,
+// until Resume is called. The jobs stay registered and scheduled,
+// and can still be run with Trigger.
+func (s *Scheduler) Pause() {
+	s.paused.Store(true)
+	s.notify(Paused, "")
+}
+
+// Resume ends a Pause
+func (s *Scheduler) Resume() {
+	s.paused.Store(false)
+	s.notify(Resumed, "")
+}
+
+// IsPaused reports whether the scheduler is paused
+func (s *Scheduler) IsPaused() bool {
+	return s.paused.Load()
 }
@@ -0,0 +1,87 @@
+package cronjobs_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/db-journey/cronjobs"
+	"github.com/db-journey/cronjobs/cronjobstest"
+	"github.com/db-journey/migrate/v2/driver"
+)
+
+// newScheduler returns a scheduler running on d, without logging the runs,
+// stopped at the end of the test.
+func newScheduler(t *testing.T, d driver.Driver, opts ...cronjobs.Option) *cronjobs.Scheduler {
+	t.Helper()
+	quiet := cronjobs.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s := cronjobs.New(d, append([]cronjobs.Option{quiet}, opts...)...)
+	t.Cleanup(s.Stop)
+	return s
+}
+
+func TestStopTwice(t *testing.T) {
+	s := newScheduler(t, &cronjobstest.FakeDriver{})
+	if err := s.AddReader("cleanup", "@yearly", strings.NewReader("SELECT 1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	s.Stop()
+	s.Stop()
+	if err := s.Start(context.Background()); !errors.Is(err, cronjobs.ErrStopped) {
+		t.Errorf("Start after Stop = %v, want ErrStopped", err)
+	}
+	if err := s.Trigger("cleanup"); !errors.Is(err, cronjobs.ErrStopped) {
+		t.Errorf("Trigger after Stop = %v, want ErrStopped", err)
+	}
+}
+
+func TestStopNotStarted(t *testing.T) {
+	s := newScheduler(t, &cronjobstest.FakeDriver{})
+	runs := s.Runs()
+	s.Stop()
+	select {
+	case _, ok := <-runs:
+		if ok {
+			t.Error("got a run, want the Runs channel closed")
+		}
+	case <-time.After(time.Second):
+		t.Error("Runs channel not closed by Stop")
+	}
+}
+
+func TestReboot(t *testing.T) {
+	d := &cronjobstest.FakeDriver{}
+	s := newScheduler(t, d)
+	if err := s.AddReader("boot", "", strings.NewReader("-- cron: @reboot\nSELECT 'boot'")); err != nil {
+		t.Fatal(err)
+	}
+	if next, _ := s.NextN("boot", 1); len(next) != 0 {
+		t.Errorf("NextN = %v, want no scheduled times", next)
+	}
+	runs := s.Runs()
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case run := <-runs:
+		if run.Name != "boot" || run.Error != nil {
+			t.Errorf("run %q: %v, want a successful run of boot", run.Name, run.Error)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("@reboot job not run at Start")
+	}
+	s.Stop()
+	for run := range runs {
+		t.Errorf("unexpected run of %q", run.Name)
+	}
+	if n := d.CountContaining("boot"); n != 1 {
+		t.Errorf("executed %d times, want 1", n)
+	}
+}
@@ -0,0 +1,102 @@
+// Package cronjobstest provides a fake driver, to test the use of a
+// cronjobs Scheduler without a database:
+//
+//	d := &cronjobstest.FakeDriver{}
+//	d.Fail("DELETE", errors.New("boom"))
+//	s := cronjobs.New(d)
+//	...
+//	s.Start(ctx)
+//	s.Trigger("cleanup")
+//	fmt.Println(d.Count(), d.Statements())
+package cronjobstest
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/db-journey/migrate/v2/driver"
+	"github.com/db-journey/migrate/v2/file"
+)
+
+// FakeDriver is a driver.Driver recording the executed statements.
+// Its zero value is ready to use, and it is safe for concurrent use.
+type FakeDriver struct {
+	mu         sync.Mutex
+	statements []string
+	failures   []failure
+}
+
+var _ driver.Driver = (*FakeDriver)(nil)
+
+// failure is an error returned for the statements containing substr
+type failure struct {
+	substr string
+	err    error
+}
+
+// Fail makes Execute return err for the statements containing substr.
+// The first matching failure is used, in the order of the Fail calls.
+func (d *FakeDriver) Fail(substr string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.failures = append(d.failures, failure{substr, err})
+}
+
+// Execute records statement, and returns the error set by Fail for it, if any
+func (d *FakeDriver) Execute(statement string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.statements = append(d.statements, statement)
+	for _, f := range d.failures {
+		if strings.Contains(statement, f.substr) {
+			return f.err
+		}
+	}
+	return nil
+}
+
+// Statements returns the executed statements, in order
+func (d *FakeDriver) Statements() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.statements...)
+}
+
+// Count returns the number of executed statements
+func (d *FakeDriver) Count() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.statements)
+}
+
+// CountContaining returns the number of executed statements containing substr
+func (d *FakeDriver) CountContaining(substr string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := 0
+	for _, statement := range d.statements {
+		if strings.Contains(statement, substr) {
+			n++
+		}
+	}
+	return n
+}
+
+// Reset forgets the executed statements, and the failures set by Fail
+func (d *FakeDriver) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.statements, d.failures = nil, nil
+}
+
+// Close does nothing
+func (d *FakeDriver) Close() error { return nil }
+
+// Migrate does nothing
+func (d *FakeDriver) Migrate(file.File) error { return nil }
+
+// Version returns 0
+func (d *FakeDriver) Version() (file.Version, error) { return 0, nil }
+
+// Versions returns no versions
+func (d *FakeDriver) Versions() (file.Versions, error) { return nil, nil }
@@ -0,0 +1,31 @@
+package cronjobstest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/db-journey/cronjobs"
+	"github.com/db-journey/cronjobs/cronjobstest"
+)
+
+func ExampleFakeDriver() {
+	d := &cronjobstest.FakeDriver{}
+	d.Fail("DELETE", errors.New("boom"))
+	s := cronjobs.New(d, cronjobs.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	s.AddReader("cleanup", "@daily", strings.NewReader("DELETE FROM sessions WHERE expired"))
+	s.AddReader("rollup", "@hourly", strings.NewReader("REFRESH MATERIALIZED VIEW sales"))
+	s.Start(context.Background())
+	defer s.Stop()
+
+	s.Trigger("cleanup")
+	s.Trigger("rollup")
+	fmt.Printf("%d %q\n", d.Count(), d.Statements())
+	fmt.Println(s.LastError("cleanup"))
+	// Output:
+	// 2 ["DELETE FROM sessions WHERE expired" "REFRESH MATERIALIZED VIEW sales"]
+	// boom
+}
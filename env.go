@@ -0,0 +1,65 @@
+package cronjobs
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// envRE matches the ${VAR} and $VAR references of job bodies.
+// Names must start with a letter or "_", leaving alone "$1" parameters
+// and "$$" quotes of SQL. A $VAR followed by "$" is a "$tag$" quote,
+// left alone by expandLine.
+var envRE = regexp.MustCompile(`\$\{([A-Za-z_]\w*)\}|\$([A-Za-z_]\w*)`)
+
+// expandEnv replaces the environment variable references of body,
+// except on the spec lines. A reference to an unset variable is left as is,
+// or is an error when strict.
+func expandEnv(body string, specLines []int, strict bool) (string, error) {
+	lines := strings.Split(body, "\n")
+	skip := make(map[int]bool, len(specLines))
+	for _, l := range specLines {
+		skip[l-1] = true
+	}
+	var unset []string
+	for i, line := range lines {
+		if skip[i] {
+			continue
+		}
+		lines[i] = expandLine(line, &unset)
+	}
+	if strict && len(unset) > 0 {
+		return "", fmt.Errorf("unset environment variables: %s", strings.Join(unset, ", "))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// expandLine replaces the environment variable references of line,
+// adding the unset ones to unset.
+func expandLine(line string, unset *[]string) string {
+	var b strings.Builder
+	prev := 0
+	for _, m := range envRE.FindAllStringSubmatchIndex(line, -1) {
+		start, end := m[0], m[1]
+		if m[4] >= 0 && end < len(line) && line[end] == '$' {
+			continue // $tag$ quote
+		}
+		var name string
+		if m[2] >= 0 {
+			name = line[m[2]:m[3]]
+		} else {
+			name = line[m[4]:m[5]]
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			*unset = append(*unset, name)
+			continue
+		}
+		b.WriteString(line[prev:start])
+		b.WriteString(value)
+		prev = end
+	}
+	b.WriteString(line[prev:])
+	return b.String()
+}
@@ -0,0 +1,47 @@
+package cronjobs_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/db-journey/cronjobs"
+	"github.com/db-journey/cronjobs/cronjobstest"
+)
+
+func TestEnvExpansionDollarQuotes(t *testing.T) {
+	t.Setenv("body", "braced")
+	t.Setenv("TABLE", "sessions")
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"DELETE FROM $TABLE", "DELETE FROM sessions"},
+		{"DELETE FROM ${TABLE}_old", "DELETE FROM sessions_old"},
+		{"DO $body$ BEGIN DELETE FROM $TABLE; END $body$", "DO $body$ BEGIN DELETE FROM sessions; END $body$"},
+		{"DO $unset$ BEGIN NULL; END $unset$", "DO $unset$ BEGIN NULL; END $unset$"},
+		{"SELECT '${body}$'", "SELECT 'braced$'"},
+		{"SELECT $1, $$text$$", "SELECT $1, $$text$$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			d := &cronjobstest.FakeDriver{}
+			s := newScheduler(t, d, cronjobs.WithStrictEnvExpansion())
+			if err := s.AddReader("job", "@yearly", strings.NewReader(tt.body)); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Start(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Trigger("job"); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.LastError("job"); err != nil {
+				t.Fatal(err)
+			}
+			if got := d.Statements(); len(got) != 1 || got[0] != tt.want {
+				t.Errorf("executed %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package cronjobs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrSkipped is wrapped by the Run errors of the jobs that were skipped,
+// rather than run: errors.Is(run.Error, ErrSkipped) tells them from the
+// failed runs. Each cause of a skip has its own error, wrapping ErrSkipped.
+var ErrSkipped = errors.New("skipped")
+
+// ErrSkippedOverlap is the Run error of a job that was skipped
+// because its previous run was still in progress.
+var ErrSkippedOverlap = fmt.Errorf("%w: previous run still in progress", ErrSkipped)
+
+// ErrLockHeld is the Run error of a job that was skipped
+// because another instance holds its lock, see WithJobLocks.
+var ErrLockHeld = fmt.Errorf("%w: lock held by another instance", ErrSkipped)
+
+// ErrMaintenanceWindow is the Run error of a job that was skipped
+// because it fired during a maintenance window.
+var ErrMaintenanceWindow = fmt.Errorf("%w: maintenance window", ErrSkipped)
+
+// ErrPaused is the Run error of a job that was skipped
+// because it fired while the scheduler was paused.
+var ErrPaused = fmt.Errorf("%w: scheduler paused", ErrSkipped)
+
+// ErrHardTimeout is the Run error of a job abandoned after the timeout
+// set by WithHardTimeout.
+var ErrHardTimeout = errors.New("abandoned: hard timeout exceeded")
+
+// ErrPredecessorFailed is the Run error of a job that was skipped
+// because the job it runs after failed, see the after= metadata.
+var ErrPredecessorFailed = fmt.Errorf("%w: predecessor failed", ErrSkipped)
+
+// ErrThrottled is the Run error of a job that was skipped because its
+// previous run started less than its minInterval= ago.
+var ErrThrottled = fmt.Errorf("%w: minimum interval not elapsed", ErrSkipped)
+
+// ErrSkippedStopping is the Run error of a job that was skipped because
+// the scheduler stopped while it waited for a WithMaxConcurrency slot.
+var ErrSkippedStopping = fmt.Errorf("%w: scheduler stopping", ErrSkipped)
+
+// ErrJobNotFound is returned when no job is registered with a given name.
+var ErrJobNotFound = errors.New("job not found")
+
+// ErrStopped is returned when starting the scheduler, or running a job,
+// after the scheduler was stopped.
+var ErrStopped = errors.New("scheduler stopped")
+
+// ErrNoJobs is returned when starting a scheduler without jobs,
+// with WithRequireJobs.
+var ErrNoJobs = errors.New("no jobs registered")
+
+// ErrNotStarted is returned when running a job before the scheduler is
+// started, as its runs could not be reported.
+var ErrNotStarted = errors.New("scheduler not started")
+
+// ErrStarted is returned when starting the scheduler twice.
+var ErrStarted = errors.New("scheduler already started")
+
+// FileError reports a job file that could not be loaded.
+// Line is the line of the file causing the error, 0 if unknown.
+type FileError struct {
+	Path string
+	Line int
+	Err  error
+}
+
+// fileError returns the FileError of fPath, at the line of err if known
+func fileError(fPath string, err error) FileError {
+	fe := FileError{Path: fPath, Err: err}
+	var le interface{ lineNumber() int }
+	if errors.As(err, &le) {
+		fe.Line = le.lineNumber()
+	}
+	return fe
+}
+
+func (e FileError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("File %s:%d: %s", e.Path, e.Line, e.Err)
+	}
+	return fmt.Sprintf("File %s: %s", e.Path, e.Err)
+}
+
+func (e FileError) Unwrap() error {
+	return e.Err
+}
+
+// FileErrors is returned by ReadFiles when some files could not be loaded.
+// The files that did load are registered anyway.
+type FileErrors []FileError
+
+func (e FileErrors) Error() string {
+	return errors.Join(e.Unwrap()...).Error()
+}
+
+func (e FileErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, fe := range e {
+		errs[i] = fe
+	}
+	return errs
+}
+
+// SpecError reports a cron spec which could not be parsed, found at Line of its file.
+type SpecError struct {
+	Line   int
+	Spec   string
+	format string
+	Err    error
+}
+
+func (e *SpecError) Error() string {
+	return fmt.Sprintf("invalid spec %q (%s): %s", e.Spec, e.format, e.Err)
+}
+
+func (e *SpecError) Unwrap() error {
+	return e.Err
+}
+
+func (e *SpecError) lineNumber() int {
+	return e.Line
+}
+
+// metaError reports an invalid metadata value of the spec line at line
+type metaError struct {
+	line  int
+	key   string
+	value string
+	err   error
+}
+
+func (e *metaError) Error() string {
+	return fmt.Sprintf("invalid %s=%q: %s", e.key, e.value, e.err)
+}
+
+func (e *metaError) Unwrap() error {
+	return e.err
+}
+
+func (e *metaError) lineNumber() int {
+	return e.line
+}
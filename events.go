@@ -0,0 +1,70 @@
+package cronjobs
+
+import "time"
+
+// EventType is the type of a lifecycle Event of the scheduler
+type EventType int
+
+const (
+	// Started is sent when the scheduler starts
+	Started EventType = iota
+	// Stopped is sent once the scheduler is stopped, and its runs are over
+	Stopped
+	// Paused is sent by Pause
+	Paused
+	// Resumed is sent by Resume
+	Resumed
+	// Reloaded is sent once Reload, or Watch, synced the jobs with their files
+	Reloaded
+	// JobAdded is sent when a job is registered, or replaced by Reload
+	JobAdded
+	// JobRemoved is sent when a job is unregistered, or replaced by Reload
+	JobRemoved
+)
+
+var eventTypes = [...]string{"started", "stopped", "paused", "resumed", "reloaded", "job added", "job removed"}
+
+func (t EventType) String() string {
+	if t < 0 || int(t) >= len(eventTypes) {
+		return "unknown"
+	}
+	return eventTypes[t]
+}
+
+// Event is a lifecycle event of the scheduler, see WithEventObserver
+type Event struct {
+	Type EventType
+	Time time.Time
+	Job  string // name of the job, for JobAdded and JobRemoved
+}
+
+// queueEvent queues an event, sent to the observers by flushEvents.
+// s.mu must be held.
+func (s *Scheduler) queueEvent(typ EventType, job string) {
+	if len(s.eventObservers) == 0 {
+		return
+	}
+	s.events = append(s.events, Event{Type: typ, Time: s.clock.Now(), Job: job})
+}
+
+// flushEvents sends the queued events to the observers.
+// s.mu must not be held, so that observers can use the scheduler.
+func (s *Scheduler) flushEvents() {
+	s.mu.Lock()
+	events := s.events
+	s.events = nil
+	s.mu.Unlock()
+	for _, ev := range events {
+		for _, observe := range s.eventObservers {
+			observe(ev)
+		}
+	}
+}
+
+// notify sends an event to the observers
+func (s *Scheduler) notify(typ EventType, job string) {
+	s.mu.Lock()
+	s.queueEvent(typ, job)
+	s.mu.Unlock()
+	s.flushEvents()
+}
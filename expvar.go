@@ -0,0 +1,32 @@
+package cronjobs
+
+import "expvar"
+
+// WithExpvar publishes the metrics of the runs with the expvar package,
+// served on /debug/vars, as a map named name with the keys:
+//   - runs_total: number of runs
+//   - failures_total: number of failed runs, not counting the skipped ones
+//   - running: number of jobs running
+//   - last_duration_seconds: map of the duration of the last run, per job
+//
+// As expvar.Publish, it panics if name is already used.
+func WithExpvar(name string) Option {
+	return func(s *Scheduler) {
+		runs, failures := new(expvar.Int), new(expvar.Int)
+		durations := new(expvar.Map).Init()
+		m := expvar.NewMap(name)
+		m.Set("runs_total", runs)
+		m.Set("failures_total", failures)
+		m.Set("running", expvar.Func(func() interface{} { return s.Running() }))
+		m.Set("last_duration_seconds", durations)
+		s.observers = append(s.observers, func(run *Run) {
+			runs.Add(1)
+			if run.Failed() {
+				failures.Add(1)
+			}
+			f := new(expvar.Float)
+			f.Set(run.Duration.Seconds())
+			durations.Set(run.Name, f)
+		})
+	}
+}
@@ -0,0 +1,545 @@
+package cronjobs
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/db-journey/migrate/v2/driver"
+	"github.com/robfig/cron/v3"
+)
+
+// ReadFiles will scan files and return a list of Jobs
+// the driver is attached to each Job to implement the cron.Job interface
+// Subdirectories are skipped, unless Recursive is set,
+// and so are files not matching Pattern.
+// The specs of the files are replaced by the WithSpecOverrides ones.
+//
+// A missing or unreadable dirname fails right away, wrapping the error of
+// os.Stat: errors.Is(err, fs.ErrNotExist) tells a missing directory. Otherwise, by default,
+// every file is tried: the ones failing to load, because they can't be read,
+// have no spec (unless SkipUnmatched is set), have an invalid spec,
+// metadata or template, or a job name already used, are reported in a
+// FileErrors, while the others are registered.
+// With WithStrict(true), the first of these failures stops the loading,
+// reported in a FileErrors too, and no job of dirname is registered.
+func (s *Scheduler) ReadFiles(dirname string) error {
+	if err := checkDir(dirname); err != nil {
+		return err
+	}
+	defer s.checkOverrides()
+	return s.readDir(dirname)
+}
+
+// readDir is ReadFiles, without checking the spec overrides
+func (s *Scheduler) readDir(dirname string) error {
+	if s.strict {
+		return s.readStrict(dirFS(dirname), ".", osPath(dirname))
+	}
+	return s.readFS(dirFS(dirname), ".", osPath(dirname), s.readFile)
+}
+
+// ReadDirs is ReadFiles, reading the files of several directories, whose
+// job names must not collide. It fails right away if one of the directories
+// can't be read. Otherwise, the files failing to load are reported in a
+// single FileErrors, with their paths in their directories.
+func (s *Scheduler) ReadDirs(dirnames ...string) error {
+	for _, dirname := range dirnames {
+		if err := checkDir(dirname); err != nil {
+			return err
+		}
+	}
+	defer s.checkOverrides()
+	var errs FileErrors
+	for _, dirname := range dirnames {
+		err := s.readDir(dirname)
+		var fileErrs FileErrors
+		if !errors.As(err, &fileErrs) {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		errs = append(errs, fileErrs...)
+		if s.strict {
+			break
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// readStrict is readFS in strict mode, loading the files of dir in fsys:
+// the jobs of all the files are only registered once they are all loaded.
+func (s *Scheduler) readStrict(fsys fs.FS, dir string, filePath func(name string) string) error {
+	var all []*job
+	paths := make(map[string]string)
+	err := s.readFS(fsys, dir, filePath, func(fsys fs.FS, dir, name, fPath string) error {
+		rel := name
+		if dir != "." {
+			rel = strings.TrimPrefix(name, dir+"/")
+		}
+		jobs, err := s.parseFile(fsys, name, s.jobName(rel), fPath)
+		if err != nil {
+			return err
+		}
+		for _, j := range jobs {
+			if other, ok := paths[j.name]; ok {
+				return fmt.Errorf("duplicate job name %q, already used by %s", j.name, other)
+			}
+			paths[j.name] = fPath
+		}
+		all = append(all, jobs...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.register(all, ""); err != nil {
+		return FileErrors{{Path: filePath(dir), Err: err}}
+	}
+	return nil
+}
+
+// checkDir fails if the job directory dirname can't be read, or is not a
+// directory, with an error wrapping the one of os.Stat, if any.
+func checkDir(dirname string) error {
+	info, err := os.Stat(dirname)
+	if err != nil {
+		return fmt.Errorf("cronjobs: reading job directory %q: %w", dirname, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("cronjobs: reading job directory %q: not a directory", dirname)
+	}
+	return nil
+}
+
+// osFS is the file system of a directory of the OS, whose file paths are
+// the OS ones, as given by osPath.
+type osFS struct {
+	fs.FS
+}
+
+// dirFS returns the file system of the directory dirname
+func dirFS(dirname string) fs.FS {
+	return osFS{os.DirFS(dirname)}
+}
+
+// osPath returns the function giving the path of the files of dirFS(dirname)
+func osPath(dirname string) func(name string) string {
+	return func(name string) string {
+		return filepath.Join(dirname, filepath.FromSlash(name))
+	}
+}
+
+// Validate checks the job files of dirname as ReadFiles would load them,
+// without registering any job: it reports, in a FileErrors, the files
+// without a spec or with an invalid one, and the duplicate job names.
+func (s *Scheduler) Validate(dirname string) error {
+	if err := checkDir(dirname); err != nil {
+		return err
+	}
+	paths := make(map[string]string)
+	return s.readFS(dirFS(dirname), ".", osPath(dirname), func(fsys fs.FS, dir, name, fPath string) error {
+		jobs, err := s.parseFile(fsys, name, s.jobName(name), fPath)
+		if err != nil {
+			return err
+		}
+		for _, j := range jobs {
+			if other, ok := paths[j.name]; ok {
+				return fmt.Errorf("duplicate job name %q, already used by %s", j.name, other)
+			}
+			paths[j.name] = fPath
+		}
+		return nil
+	})
+}
+
+// ReadFile reads the job file at fPath, named after the file name.
+// It fails with a FileError.
+func (s *Scheduler) ReadFile(fPath string) error {
+	dir, name := filepath.Split(fPath)
+	if dir == "" {
+		dir = "."
+	}
+	if err := s.readFile(dirFS(dir), ".", name, fPath); err != nil {
+		return fileError(fPath, err)
+	}
+	return nil
+}
+
+// ReadFS is like ReadFiles, reading the files of dir in fsys.
+// It allows to load jobs embedded in the binary:
+//
+//	//go:embed jobs
+//	var jobs embed.FS
+//	...
+//	err := s.ReadFS(jobs, "jobs")
+func (s *Scheduler) ReadFS(fsys fs.FS, dir string) error {
+	if s.strict {
+		return s.readStrict(fsys, dir, func(name string) string { return name })
+	}
+	return s.readFS(fsys, dir, func(name string) string { return name }, s.readFile)
+}
+
+// readFS loads the job files of dir in fsys with load.
+// filePath gives the path of a file from its name in fsys, for reporting.
+func (s *Scheduler) readFS(fsys fs.FS, dir string, filePath func(name string) string, load func(fsys fs.FS, dir, name, fPath string) error) error {
+	var errs FileErrors
+
+	// find all cronjobs files in path.
+	err := fs.WalkDir(fsys, dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if name == dir {
+				return err
+			}
+			errs = append(errs, fileError(filePath(name), err))
+			if s.strict {
+				return fs.SkipAll
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if name != dir && !s.Recursive {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if ok, err := s.matches(d.Name()); !ok {
+			return err
+		}
+		if err := load(fsys, dir, name, filePath(name)); err != nil {
+			errs = append(errs, fileError(filePath(name), err))
+			if s.strict {
+				return fs.SkipAll
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// matches reports whether a file name matches Pattern
+func (s *Scheduler) matches(name string) (bool, error) {
+	if s.Pattern == "" {
+		return true, nil
+	}
+	return path.Match(s.Pattern, name)
+}
+
+// readFile parses the file name of fsys and registers its job.
+func (s *Scheduler) readFile(fsys fs.FS, dir, name, fPath string) error {
+	rel := name
+	if dir != "." {
+		rel = strings.TrimPrefix(name, dir+"/")
+	}
+	jobs, err := s.parseFile(fsys, name, s.jobName(rel), fPath)
+	if err != nil {
+		return err
+	}
+	return s.register(jobs, "")
+}
+
+// parseFile parses the file name of fsys into its jobs.
+// It returns no jobs, and no error, for a file without a spec when SkipUnmatched is set.
+func (s *Scheduler) parseFile(fsys fs.FS, name, jobName, fPath string) ([]*job, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	content := string(data)
+	specs, err := s.parseSpecs(content)
+	if err != nil {
+		return nil, err
+	}
+	if len(specs) == 0 && s.SkipUnmatched {
+		return nil, nil
+	}
+	jobs, err := s.parseJobs(jobName, fPath, content, specs)
+	absPath := ""
+	if _, ok := fsys.(osFS); ok {
+		absPath, _ = filepath.Abs(fPath)
+	}
+	for _, j := range jobs {
+		j.source = &source{fsys, name, jobName}
+		j.absPath = absPath
+	}
+	return jobs, err
+}
+
+// jobName derives a job name from the path of its file relative to the
+// jobs directory: the path without extension, and with path separators
+// replaced by "_".
+func jobName(rel string) string {
+	name := strings.TrimSuffix(rel, path.Ext(rel))
+	return strings.ReplaceAll(name, "/", "_")
+}
+
+// jobName derives a job name from the path of its file relative to the
+// jobs directory, with the function set by WithNameFunc if any.
+func (s *Scheduler) jobName(rel string) string {
+	if s.nameFunc != nil {
+		return s.nameFunc(rel)
+	}
+	return jobName(rel)
+}
+
+// AddReader registers a job named name, executing the content read from r.
+// The job runs on spec, or on the specs found in the content when spec is empty.
+func (s *Scheduler) AddReader(name, spec string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	content := string(data)
+	specs := []specLine{newSpecLine(spec, 0)}
+	if spec == "" {
+		if specs, err = s.parseSpecs(content); err != nil {
+			return err
+		}
+	}
+	jobs, err := s.parseJobs(name, "", content, specs)
+	if err != nil {
+		return err
+	}
+	return s.register(jobs, "")
+}
+
+// AddJob registers a job named name, calling fn on spec instead of
+// executing statements on the driver. Its runs are reported, retried and
+// kept from overlapping as the ones of the other jobs, and fn is given
+// the context cancelled by WithJobTimeout. The spec can be followed by
+// metadata, as on spec lines. It returns the cron entry of the job,
+// 0 if it is not scheduled.
+func (s *Scheduler) AddJob(name, spec string, fn func(context.Context) error) (cron.EntryID, error) {
+	jobs, err := s.parseJobs(name, "", "", []specLine{newSpecLine(spec, 0)})
+	if err != nil {
+		return 0, err
+	}
+	jobs[0].fn = fn
+	if err := s.register(jobs, ""); err != nil {
+		return 0, err
+	}
+	return jobs[0].id, nil
+}
+
+// parseJobs returns the jobs of a file content, one for each of its specs.
+// fPath is the file the content was read from, if any.
+func (s *Scheduler) parseJobs(jobName, fPath, content string, specs []specLine) ([]*job, error) {
+	if len(specs) == 0 {
+		return nil, errors.New(`Cron spec ("[...]cron: [spec]") was not found`)
+	}
+
+	body := content
+	var tmpl *template.Template
+	var specLines []int
+	if s.templating != nil {
+		// spec lines are left out of templates
+		var err error
+		if body, tmpl, err = s.parseTemplate(jobName, content, specs); err != nil {
+			return nil, fmt.Errorf("template: %w", err)
+		}
+	} else {
+		for _, spec := range specs {
+			specLines = append(specLines, spec.lines()...)
+		}
+	}
+
+	var hash [sha256.Size]byte
+	if s.dupBodies {
+		hash = bodyHash(content, specs)
+	}
+	jobs := make([]*job, len(specs))
+	for i, spec := range specs {
+		var schedule cron.Schedule
+		var err error
+		// a job run after another one may have no spec
+		if spec.spec != rebootSpec && (spec.spec != "" || spec.meta["after"] == "") {
+			if schedule, err = s.parser.Parse(spec.spec); err != nil {
+				return nil, &SpecError{spec.line, spec.spec, s.specFormat(), err}
+			}
+		}
+		jobs[i] = &job{
+			name:        jobName,
+			spec:        spec.spec,
+			schedule:    schedule,
+			path:        fPath,
+			content:     content,
+			body:        body,
+			tmpl:        tmpl,
+			specLines:   specLines,
+			description: spec.meta["desc"],
+			atStart:     spec.spec == rebootSpec,
+			after:       spec.meta["after"],
+			hash:        hash,
+		}
+		if name := spec.meta["name"]; name != "" {
+			jobs[i].name = name
+		} else if len(specs) > 1 {
+			jobs[i].name = fmt.Sprintf("%s#%d", jobName, i+1)
+		}
+		if override, ok := s.overrides[jobs[i].name]; ok {
+			if err := s.override(jobs[i], override); err != nil {
+				return nil, err
+			}
+		}
+		if tags := spec.meta["tags"]; tags != "" {
+			jobs[i].tags = strings.Split(tags, ",")
+		}
+		if jobs[i].enabled, err = spec.boolMeta("enabled", true); err != nil {
+			return nil, err
+		}
+		if runAtStart, err := spec.boolMeta("runAtStart", false); err != nil {
+			return nil, err
+		} else if runAtStart {
+			jobs[i].atStart = true
+		}
+		if level, ok := spec.meta["logLevel"]; ok {
+			jobs[i].logLevel = new(slog.Level)
+			if err := jobs[i].logLevel.UnmarshalText([]byte(level)); err != nil {
+				return nil, &metaError{spec.line, "logLevel", level, err}
+			}
+		}
+		if jobs[i].priority, err = spec.priorityMeta(); err != nil {
+			return nil, err
+		}
+		if jobs[i].timeout, err = spec.durationMeta("timeout", s.jobTimeout); err != nil {
+			return nil, err
+		}
+		if jobs[i].minInterval, err = spec.durationMeta("minInterval", 0); err != nil {
+			return nil, err
+		}
+		if jobs[i].jitter, err = spec.durationMeta("jitter", s.jitter); err != nil {
+			return nil, err
+		}
+		if jobs[i].driver, err = s.jobDriver(spec); err != nil {
+			return nil, err
+		}
+	}
+	return jobs, nil
+}
+
+// jobDriver returns the driver of the job of spec, named by its driver=
+// metadata, or the default driver.
+func (s *Scheduler) jobDriver(spec specLine) (driver.Driver, error) {
+	name, ok := spec.meta["driver"]
+	if !ok {
+		return s.driver, nil
+	}
+	d, ok := s.drivers[name]
+	if !ok {
+		return nil, &metaError{spec.line, "driver", name, errors.New("unknown driver")}
+	}
+	return d, nil
+}
+
+// register schedules jobs, all of them or none if one of their names is used
+// twice among them, or already registered. When replace is not empty, the jobs
+// loaded from the file replace are unregistered, and their names can be reused.
+func (s *Scheduler) register(jobs []*job, replace string) error {
+	defer s.flushEvents()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make(map[string]bool, len(jobs))
+	for _, j := range jobs {
+		if names[j.name] {
+			return fmt.Errorf("duplicate job name %q", j.name)
+		}
+		names[j.name] = true
+		other, ok := s.jobs[j.name]
+		if !ok || (replace != "" && other.path == replace) {
+			continue
+		}
+		if other.path == "" {
+			return fmt.Errorf("duplicate job name %q", j.name)
+		}
+		return fmt.Errorf("duplicate job name %q, already used by %s", j.name, other.path)
+	}
+	if err := s.checkCycles(jobs, replace); err != nil {
+		return err
+	}
+	if s.dupBodies {
+		if err := s.checkBodies(jobs, replace); err != nil {
+			return err
+		}
+	}
+	if replace != "" {
+		s.unregisterFile(replace)
+	}
+	for _, j := range jobs {
+		s.jobs[j.name] = j
+		s.queueEvent(JobAdded, j.name)
+		if j.enabled && j.schedule != nil {
+			s.schedule(j)
+		}
+	}
+	return nil
+}
+
+// bodyHash returns the hash of content, without its spec lines and blanks
+// around, to find the files with the same statements.
+func bodyHash(content string, specs []specLine) [sha256.Size]byte {
+	lines := strings.Split(content, "\n")
+	for _, spec := range specs {
+		for _, l := range spec.lines() {
+			if l <= len(lines) {
+				lines[l-1] = ""
+			}
+		}
+	}
+	return sha256.Sum256([]byte(strings.TrimSpace(strings.Join(lines, "\n"))))
+}
+
+// checkBodies reports the jobs with the same body as a job of another file,
+// registered or among jobs, ignoring the jobs of the file replace: with a
+// warning, or an error in strict mode. s.mu must be held.
+func (s *Scheduler) checkBodies(jobs []*job, replace string) error {
+	paths := make(map[[sha256.Size]byte]string)
+	for _, j := range s.jobs {
+		if j.path != "" && j.path != replace {
+			paths[j.hash] = j.path
+		}
+	}
+	for _, j := range jobs {
+		if j.path == "" {
+			continue
+		}
+		if other, ok := paths[j.hash]; ok && other != j.path {
+			if s.strict {
+				return fmt.Errorf("%s has the same body as %s", j.path, other)
+			}
+			s.warn("same job body in two files", "file", j.path, "other", other)
+		}
+		paths[j.hash] = j.path
+	}
+	return nil
+}
+
+// unregisterFile removes the jobs loaded from the file fPath.
+// s.mu must be held.
+func (s *Scheduler) unregisterFile(fPath string) {
+	for name, j := range s.jobs {
+		if j.path == fPath {
+			s.unschedule(j)
+			delete(s.jobs, name)
+			s.queueEvent(JobRemoved, name)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 module github.com/db-journey/cronjobs
 
-go 1.13
+go 1.21
 
 require (
 	github.com/db-journey/migrate/v2 v2.1.1
+	github.com/fsnotify/fsnotify v1.7.0
 	github.com/robfig/cron/v3 v3.0.0
 )
+
+require golang.org/x/sys v0.17.0 // indirect
@@ -0,0 +1,100 @@
+package cronjobs
+
+import (
+	"errors"
+	"sync"
+)
+
+// history keeps the last runs of each job
+type history struct {
+	mu   sync.Mutex
+	size int
+	jobs map[string]*ring
+}
+
+// ring is a ring buffer of runs
+type ring struct {
+	runs []Run
+	next int // index of the next run to record, once the buffer is full
+}
+
+// record adds run to the history of its job. h.mu must be held.
+func (h *history) record(run *Run) {
+	if h.size <= 0 {
+		return
+	}
+	if h.jobs == nil {
+		h.jobs = make(map[string]*ring)
+	}
+	r, ok := h.jobs[run.Name]
+	if !ok {
+		r = &ring{runs: make([]Run, 0, h.size)}
+		h.jobs[run.Name] = r
+	}
+	if len(r.runs) < h.size {
+		r.runs = append(r.runs, *run)
+		return
+	}
+	r.runs[r.next] = *run
+	r.next = (r.next + 1) % h.size
+}
+
+// History returns the last runs of the job with the given name, oldest first.
+// The number of runs kept per job is set by WithHistorySize.
+func (s *Scheduler) History(name string) []Run {
+	s.history.mu.Lock()
+	defer s.history.mu.Unlock()
+	r, ok := s.history.jobs[name]
+	if !ok {
+		return nil
+	}
+	runs := make([]Run, 0, len(r.runs))
+	runs = append(runs, r.runs[r.next:]...)
+	return append(runs, r.runs[:r.next]...)
+}
+
+// LastRun returns the last run of the job with the given name,
+// and false if it has not run yet.
+func (s *Scheduler) LastRun(name string) (Run, bool) {
+	s.history.mu.Lock()
+	defer s.history.mu.Unlock()
+	return s.history.last(name)
+}
+
+// last returns the last run of the job with the given name. h.mu must be held.
+func (h *history) last(name string) (Run, bool) {
+	r, ok := h.jobs[name]
+	if !ok {
+		return Run{}, false
+	}
+	last := r.next - 1
+	if len(r.runs) < h.size || last < 0 {
+		last = len(r.runs) - 1
+	}
+	return r.runs[last], true
+}
+
+// LastError returns the error of the last run of the job with the given
+// name which was not skipped, nil if it succeeded or the job has not run yet.
+// It relies on the history, which must not be disabled by WithHistorySize.
+func (s *Scheduler) LastError(name string) error {
+	runs := s.History(name)
+	for i := len(runs) - 1; i >= 0; i-- {
+		if !errors.Is(runs[i].Error, ErrSkipped) {
+			return runs[i].Error
+		}
+	}
+	return nil
+}
+
+// AnyFailing returns the names of the registered jobs whose last run
+// failed, sorted, as LastError tells: a skipped run does not make a job fail.
+func (s *Scheduler) AnyFailing() []string {
+	var failing []string
+	for _, info := range s.List() {
+		if s.LastError(info.Name) != nil {
+			failing = append(failing, info.Name)
+		}
+	}
+	return failing
+}
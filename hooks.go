@@ -0,0 +1,97 @@
+package cronjobs
+
+import "sync"
+
+// hooks calls the OnSuccess and OnFailure callbacks with the runs.
+// Each job has its own queue of runs, drained by a goroutine in order,
+// so that callbacks don't hold up jobs, and see the runs of a job in order.
+type hooks struct {
+	mu        sync.Mutex
+	onSuccess []func(*Run)
+	onFailure []func(*Run)
+	onRun     []func(*Run) // called with every run, skipped ones included
+	queues    map[string]*hookQueue
+	wg        sync.WaitGroup
+}
+
+// hookQueue is the queue of runs of a job, waiting for their callbacks
+type hookQueue struct {
+	runs     []*Run
+	draining bool
+}
+
+// OnSuccess registers f, to be called with each successful run.
+//
+// Callbacks are called in a goroutine of their own, and not from the job,
+// so that they may be slow: the callbacks of a job are called in the order
+// of its runs. A panic in a callback is recovered.
+func (s *Scheduler) OnSuccess(f func(*Run)) {
+	s.hooks.mu.Lock()
+	defer s.hooks.mu.Unlock()
+	s.hooks.onSuccess = append(s.hooks.onSuccess, f)
+}
+
+// OnFailure registers f, to be called with each failed run, as OnSuccess callbacks are.
+// The skipped runs, see ErrSkipped, are neither given to the OnSuccess nor
+// to the OnFailure callbacks.
+func (s *Scheduler) OnFailure(f func(*Run)) {
+	s.hooks.mu.Lock()
+	defer s.hooks.mu.Unlock()
+	s.hooks.onFailure = append(s.hooks.onFailure, f)
+}
+
+// enqueue queues run for the callbacks, if any
+func (h *hooks) enqueue(run *Run) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.onSuccess) == 0 && len(h.onFailure) == 0 && len(h.onRun) == 0 {
+		return
+	}
+	if h.queues == nil {
+		h.queues = make(map[string]*hookQueue)
+	}
+	q, ok := h.queues[run.Name]
+	if !ok {
+		q = &hookQueue{}
+		h.queues[run.Name] = q
+	}
+	q.runs = append(q.runs, run)
+	if !q.draining {
+		q.draining = true
+		h.wg.Add(1)
+		go h.drain(q)
+	}
+}
+
+// drain calls the callbacks with the runs of q, until it is empty
+func (h *hooks) drain(q *hookQueue) {
+	defer h.wg.Done()
+	for {
+		h.mu.Lock()
+		if len(q.runs) == 0 {
+			q.draining = false
+			h.mu.Unlock()
+			return
+		}
+		run := q.runs[0]
+		q.runs = q.runs[1:]
+		callbacks := append([]func(*Run){}, h.onRun...)
+		switch {
+		case run.Failed():
+			callbacks = append(callbacks, h.onFailure...)
+		case run.Error == nil:
+			callbacks = append(callbacks, h.onSuccess...)
+		}
+		h.mu.Unlock()
+
+		for _, f := range callbacks {
+			call(f, run)
+		}
+	}
+}
+
+// call calls f(run), recovering from a panic
+func call(f func(*Run), run *Run) {
+	defer func() { recover() }()
+	f(run)
+}
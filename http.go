@@ -0,0 +1,130 @@
+package cronjobs
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// jobStatus is the JSON status of a job, served by Handler
+type jobStatus struct {
+	Name        string     `json:"name"`
+	Spec        string     `json:"spec"`
+	Description string     `json:"description,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Enabled     bool       `json:"enabled"`
+	Path        string     `json:"path,omitempty"`
+	Next        *time.Time `json:"next,omitempty"`
+	Runs        uint64     `json:"runs"`
+	Failures    uint64     `json:"failures"`
+	LastRun     *runStatus `json:"last_run,omitempty"`
+}
+
+// runStatus is the JSON form of a Run
+type runStatus struct {
+	ID        uint64        `json:"id"`
+	Name      string        `json:"name"`
+	Error     string        `json:"error,omitempty"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Attempt   int           `json:"attempt,omitempty"`
+	// RowsAffected is omitted when unknown
+	RowsAffected *int64 `json:"rows_affected,omitempty"`
+	DryRun       bool   `json:"dry_run,omitempty"`
+}
+
+func newRunStatus(run Run) *runStatus {
+	rs := &runStatus{
+		ID:           run.ID,
+		Name:         run.Name,
+		StartedAt:    run.StartedAt,
+		Duration:     run.Duration,
+		Attempt:      run.Attempt,
+		RowsAffected: run.RowsAffected,
+		DryRun:       run.DryRun,
+	}
+	if run.Error != nil {
+		rs.Error = run.Error.Error()
+	}
+	return rs
+}
+
+// Handler returns an HTTP handler serving the status of the jobs as JSON,
+// with their next and last runs, and their counters. It also runs a job
+// on POST .../trigger/<job name> (or .../trigger?name=<job name>),
+// answering with the resulting run once it is over: 404 if there is
+// no such job, and 409 if the job was skipped, like when it is already running.
+// It is meant to be mounted on a path of an admin server:
+//
+//	http.Handle("/cronjobs/", http.StripPrefix("/cronjobs", s.Handler()))
+func (s *Scheduler) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/trigger") {
+			s.serveTrigger(w, r, r.URL.Query().Get("name"))
+			return
+		}
+		if i := strings.Index(r.URL.Path, "/trigger/"); i >= 0 {
+			s.serveTrigger(w, r, r.URL.Path[i+len("/trigger/"):])
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		s.serveStatus(w)
+	})
+}
+
+// serveStatus writes the status of all the jobs
+func (s *Scheduler) serveStatus(w http.ResponseWriter) {
+	infos := s.List()
+	statuses := make([]jobStatus, len(infos))
+	for i, info := range infos {
+		st := jobStatus{
+			Name:        info.Name,
+			Spec:        info.Spec,
+			Description: info.Description,
+			Tags:        info.Tags,
+			Enabled:     info.Enabled,
+			Path:        info.Path,
+		}
+		if !info.Next.IsZero() {
+			st.Next = &info.Next
+		}
+		st.Runs, st.Failures = s.Stats(info.Name)
+		if run, ok := s.LastRun(info.Name); ok {
+			st.LastRun = newRunStatus(*s.redact(&run))
+		}
+		statuses[i] = st
+	}
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+// serveTrigger runs the job with the given name
+func (s *Scheduler) serveTrigger(w http.ResponseWriter, r *http.Request, name string) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	run, err := s.trigger(name)
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(run.Error, ErrSkipped):
+		writeJSON(w, http.StatusConflict, newRunStatus(*s.redact(run)))
+	default:
+		writeJSON(w, http.StatusOK, newRunStatus(*s.redact(run)))
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,569 @@
+package cronjobs
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"math/rand"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/db-journey/migrate/v2/driver"
+	"github.com/robfig/cron/v3"
+)
+
+// job is a job read from a file, and registered in the scheduler
+type job struct {
+	name     string
+	spec     string
+	schedule cron.Schedule // nil for a job only run at start, with the @reboot spec
+	path     string        // as given to the loading method, for reporting
+	absPath  string        // absolute path of a file read from the OS
+	source   *source       // to read the file again, for ReloadJob
+	driver   driver.Driver // driver selected by the driver= metadata, or the default one
+	content  string
+	fn       func(context.Context) error // called instead of executing the body, with AddJob
+	body     string                      // statement executed, from content
+	tmpl     *template.Template          // template rendered at each run, if any, instead of body
+	// lines of body holding a spec (1-based), left alone by the env expansion
+	specLines []int
+	// metadata from the spec line
+	description string
+	tags        []string
+	enabled     bool   // disabled jobs are not scheduled
+	atStart     bool   // run once by Start, with @reboot or runAtStart=true
+	after       string // job whose successful runs trigger this one
+	minInterval time.Duration
+	timeout     time.Duration     // from the timeout= metadata, or WithJobTimeout
+	priority    int               // to get a WithMaxConcurrency slot
+	hash        [sha256.Size]byte // of the body, with WithDuplicateBodyCheck
+	jitter      time.Duration
+	logLevel    *slog.Level // level of the successful runs, if not the default
+
+	id cron.EntryID // cron entry, to manage the job once registered
+
+	running   atomic.Bool
+	lastStart atomic.Int64 // start of the last run, in Unix nanoseconds, with a minInterval
+}
+
+// source is the file a job was read from, name in fsys,
+// giving jobName to its jobs.
+type source struct {
+	fsys    fs.FS
+	name    string
+	jobName string
+}
+
+// rebootSpec is the spec of the jobs run once, when the scheduler starts
+const rebootSpec = "@reboot"
+
+// JobInfo describes a registered job
+type JobInfo struct {
+	Name        string
+	Spec        string
+	Description string
+	Tags        []string
+	Enabled     bool
+	Next        time.Time // zero until the scheduler is started, or if disabled
+	// Path is the absolute path of the file the job was read from,
+	// empty for the jobs not read from the OS, like by ReadFS or AddJob.
+	Path string
+}
+
+// List returns the registered jobs, sorted by name
+func (s *Scheduler) List() []JobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	infos := make([]JobInfo, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		infos = append(infos, s.info(j))
+	}
+	sort.Slice(infos, func(a, b int) bool { return infos[a].Name < infos[b].Name })
+	return infos
+}
+
+// info returns the JobInfo of j
+func (s *Scheduler) info(j *job) JobInfo {
+	return JobInfo{
+		Name:        j.name,
+		Spec:        j.spec,
+		Description: j.description,
+		Tags:        append([]string(nil), j.tags...),
+		Enabled:     j.enabled,
+		Next:        s.Entry(j.id).Next,
+		Path:        j.absPath,
+	}
+}
+
+// Trigger runs the job with the given name right away, and returns once it is over.
+// The run is reported like scheduled runs are, so the scheduler must be
+// started: Trigger fails with ErrNotStarted before Start.
+func (s *Scheduler) Trigger(name string) error {
+	_, err := s.trigger(name)
+	return err
+}
+
+// trigger is Trigger, also returning the last Run of the job
+func (s *Scheduler) trigger(name string) (*Run, error) {
+	s.mu.Lock()
+	started := s.started
+	s.mu.Unlock()
+	if !started {
+		return nil, ErrNotStarted
+	}
+	j, err := s.job(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.run(j)
+}
+
+// Next returns the next time the job with the given name runs,
+// zero until the scheduler is started.
+func (s *Scheduler) Next(name string) (time.Time, error) {
+	j, err := s.job(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return s.Entry(j.id).Next, nil
+}
+
+// NextN returns the next n times the job with the given name runs on its
+// spec, from now, whether the scheduler is started or not.
+// It returns no times for a job without a schedule, like @reboot ones,
+// and an error if n is negative.
+func (s *Scheduler) NextN(name string, n int) ([]time.Time, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("negative number of times: %d", n)
+	}
+	j, err := s.job(name)
+	if err != nil {
+		return nil, err
+	}
+	if j.schedule == nil {
+		return nil, nil
+	}
+	times := make([]time.Time, 0, n)
+	for t := s.clock.Now().In(s.location); len(times) < n; {
+		t = j.schedule.Next(t)
+		if t.IsZero() {
+			break // the spec never matches
+		}
+		times = append(times, t)
+	}
+	return times, nil
+}
+
+// Remove unregisters the job with the given name.
+// A run of the job in progress is not interrupted.
+func (s *Scheduler) Remove(name string) error {
+	defer s.flushEvents()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrJobNotFound, name)
+	}
+	s.unschedule(j)
+	delete(s.jobs, name)
+	s.queueEvent(JobRemoved, name)
+	return nil
+}
+
+// job returns the registered job with the given name
+func (s *Scheduler) job(name string) (*job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrJobNotFound, name)
+	}
+	return j, nil
+}
+
+// fire runs the job on its schedule, after a random delay up to its jitter.
+// The delay is cut short when the scheduler is stopped, the job then not being run.
+// The job is skipped while the scheduler is paused, or during a maintenance window.
+func (s *Scheduler) fire(j *job) {
+	if j.jitter > 0 {
+		select {
+		case <-s.clock.After(time.Duration(rand.Int63n(int64(j.jitter)))):
+		case <-s.stopping:
+			return
+		}
+	}
+	if s.IsPaused() {
+		s.skip(j, ErrPaused)
+		return
+	}
+	if s.inMaintenance(s.clock.Now()) {
+		s.skip(j, ErrMaintenanceWindow)
+		return
+	}
+	s.run(j)
+}
+
+// skip reports a run of the job skipped with err, without running it
+func (s *Scheduler) skip(j *job, err error) {
+	if !s.begin() {
+		return
+	}
+	defer s.inflight.Done()
+	s.emit(j, &Run{
+		ID:        s.runID.Add(1),
+		Name:      j.name,
+		Error:     err,
+		StartedAt: s.clock.Now(),
+		final:     true,
+	})
+}
+
+// run executes the job, and sends the resulting Run on the runs channel.
+// A job is never run concurrently with itself: if the previous run is not
+// over yet, the new one is skipped with ErrSkippedOverlap, and if it
+// started less than its minInterval ago, with ErrThrottled.
+// Failed runs are retried as configured by WithRetry, each attempt
+// sending its own Run. When the scheduler stops before a retry, a last Run
+// is sent, failed with the error of the last attempt.
+// All the Runs of a firing share the same ID.
+// The jobs run after it are then triggered, see chain.
+// It returns the last Run, or ErrStopped without running the job
+// once the scheduler is stopped.
+func (s *Scheduler) run(j *job) (*Run, error) {
+	if !s.begin() {
+		return nil, ErrStopped
+	}
+	defer s.inflight.Done()
+	id := s.runID.Add(1)
+
+	if !j.running.CompareAndSwap(false, true) {
+		run := &Run{
+			ID:        id,
+			Name:      j.name,
+			Error:     ErrSkippedOverlap,
+			StartedAt: s.clock.Now(),
+			final:     true,
+		}
+		s.emit(j, run)
+		return run, nil
+	}
+	defer j.running.Store(false)
+
+	if now := s.clock.Now(); j.minInterval > 0 {
+		if last := j.lastStart.Load(); last != 0 && now.Sub(time.Unix(0, last)) < j.minInterval {
+			run := &Run{
+				ID:        id,
+				Name:      j.name,
+				Error:     ErrThrottled,
+				StartedAt: now,
+				final:     true,
+			}
+			s.emit(j, run)
+			return run, nil
+		}
+		j.lastStart.Store(now.UnixNano())
+	}
+
+	if s.locks && !s.dryRun {
+		run, unlock := s.lock(j)
+		if run != nil {
+			run.ID, run.StartedAt, run.final = id, s.clock.Now(), true
+			s.emit(j, run)
+			return run, nil
+		}
+		defer unlock()
+	}
+
+	for attempt := 1; ; attempt++ {
+		run := s.execute(j)
+		run.ID, run.Attempt = id, attempt
+		if !run.Failed() || attempt >= s.retry.attempts || s.isStopping() {
+			if run.Failed() && attempt > 1 {
+				run.Error = fmt.Errorf("failed after %d attempts: %w", attempt, run.Error)
+			}
+			run.final = true
+			s.emit(j, run)
+			s.chain(j, run)
+			return run, nil
+		}
+		s.emit(j, run)
+
+		select {
+		case <-s.clock.After(s.retry.delay(attempt)):
+		case <-s.stopping:
+			// the failed attempt was not the last one: report the firing is over
+			last := &Run{
+				ID:        id,
+				Name:      j.name,
+				Error:     fmt.Errorf("retry abandoned, scheduler stopped: %w", run.Error),
+				Attempt:   attempt,
+				StartedAt: s.clock.Now(),
+				final:     true,
+			}
+			s.emit(j, last)
+			return last, nil
+		}
+	}
+}
+
+// JobLocker is implemented by drivers able to take locks shared by all the
+// instances of a scheduler using the same database, like advisory locks,
+// used by WithJobLocks.
+type JobLocker interface {
+	// TryLock takes the lock of the given name, without waiting:
+	// it returns false if the lock is held by another instance.
+	TryLock(name string) (bool, error)
+	// Unlock releases the lock of the given name.
+	Unlock(name string) error
+}
+
+// lockName returns the name of the lock of a job
+func lockName(j *job) string {
+	return "cronjobs:" + j.name
+}
+
+// lock takes the lock of the job, returning the function releasing it,
+// or the Run reporting the job is skipped, when the lock can't be taken.
+func (s *Scheduler) lock(j *job) (*Run, func()) {
+	locker, ok := j.driver.(JobLocker)
+	if !ok {
+		return &Run{Name: j.name, Error: fmt.Errorf("job locks: driver %T is not a JobLocker", j.driver)}, nil
+	}
+	ok, err := locker.TryLock(lockName(j))
+	if err != nil {
+		return &Run{Name: j.name, Error: fmt.Errorf("taking lock: %w", err)}, nil
+	}
+	if !ok {
+		return &Run{Name: j.name, Error: ErrLockHeld}, nil
+	}
+	return nil, func() {
+		if err := locker.Unlock(lockName(j)); err != nil {
+			s.warn("releasing lock", "job", j.name, "error", err)
+		}
+	}
+}
+
+// isStopping reports whether the scheduler is stopping
+func (s *Scheduler) isStopping() bool {
+	select {
+	case <-s.stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// begin registers a job run in progress, unless the scheduler is stopped
+func (s *Scheduler) begin() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return false
+	}
+	s.inflight.Add(1)
+	return true
+}
+
+// execute runs the job content once on the driver.
+// A panic during the execution is recovered, and reported as the Run error.
+func (s *Scheduler) execute(j *job) *Run {
+	if s.sem != nil {
+		if err := s.sem.acquire(s.stopCtx, j.priority); err != nil {
+			return &Run{Name: j.name, Error: ErrSkippedStopping, StartedAt: s.clock.Now()}
+		}
+		defer s.sem.release()
+	}
+	s.executing.Add(1)
+	defer s.executing.Add(-1)
+
+	ctx := s.runCtx
+	if j.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.timeout)
+		defer cancel()
+	}
+
+	start := s.clock.Now()
+	var rows *int64
+	var err error
+	if s.hardTimeout > 0 {
+		rows, err = s.executeHard(ctx, j)
+	} else {
+		rows, err = s.executeJob(ctx, j)
+	}
+	if err != nil && ctx.Err() != nil && !errors.Is(err, ctx.Err()) {
+		err = fmt.Errorf("%w: %s", ctx.Err(), err)
+	}
+	return &Run{
+		Name:         j.name,
+		Error:        err,
+		Duration:     s.clock.Now().Sub(start),
+		StartedAt:    start,
+		RowsAffected: rows,
+		DryRun:       s.dryRun,
+	}
+}
+
+// executeJob renders the body of j and executes it, or calls its fn,
+// unless in dry-run mode.
+// A panic is recovered, and returned as the error.
+func (s *Scheduler) executeJob(ctx context.Context, j *job) (rows *int64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			rows, err = nil, fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	if j.fn != nil {
+		if s.dryRun {
+			return nil, nil
+		}
+		return nil, j.fn(ctx)
+	}
+	body, err := s.body(j)
+	if err == nil && !s.dryRun {
+		rows, err = s.executeBody(ctx, j, body)
+	}
+	return rows, err
+}
+
+// executeHard is executeJob, in a goroutine abandoned after the hard
+// timeout: the execution may then still be running, as is logged.
+func (s *Scheduler) executeHard(ctx context.Context, j *job) (*int64, error) {
+	type result struct {
+		rows *int64
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		rows, err := s.executeJob(ctx, j)
+		done <- result{rows, err}
+	}()
+	select {
+	case r := <-done:
+		return r.rows, r.err
+	case <-s.clock.After(s.hardTimeout):
+		s.warn("job abandoned after its hard timeout, its execution may still be running", "job", j.name)
+		return nil, ErrHardTimeout
+	}
+}
+
+// body returns the statement to execute for a run of j
+func (s *Scheduler) body(j *job) (string, error) {
+	body := j.body
+	if j.tmpl != nil {
+		var err error
+		if body, err = render(j.tmpl, s.templating.data()); err != nil {
+			return "", fmt.Errorf("template: %w", err)
+		}
+	}
+	if s.envExpansion != noExpansion {
+		var err error
+		if body, err = expandEnv(body, j.specLines, s.envExpansion == strictExpansion); err != nil {
+			return "", err
+		}
+	}
+	return body, nil
+}
+
+// executeBody executes body on the driver of j: as a whole, or one
+// statement after the other with WithStatementSplit, and in a transaction
+// with WithTransaction.
+// It returns the number of rows affected by the statements, nil if the
+// driver can't report it.
+func (s *Scheduler) executeBody(ctx context.Context, j *job, body string) (*int64, error) {
+	statements := []string{body}
+	if s.split {
+		// the spec lines are comments, which would be sent with the first statement
+		lines := strings.Split(body, "\n")
+		for _, l := range j.specLines {
+			if l > 0 && l <= len(lines) {
+				lines[l-1] = ""
+			}
+		}
+		statements = splitStatements(strings.Join(lines, "\n"))
+	}
+
+	if tx, ok := j.driver.(TxExecutor); ok && s.tx {
+		if err := tx.ExecuteTx(ctx, statements); err != nil {
+			return nil, fmt.Errorf("transaction rolled back: %w", err)
+		}
+		return nil, nil
+	}
+	var rows *int64
+	if _, ok := j.driver.(ResultExecutor); ok {
+		rows = new(int64)
+	}
+	for i, statement := range statements {
+		n, err := executeContext(ctx, j.driver, statement)
+		if rows != nil {
+			*rows += n
+		}
+		if err != nil {
+			if s.split {
+				return rows, fmt.Errorf("statement %d: %w", i+1, err)
+			}
+			return rows, err
+		}
+	}
+	return rows, nil
+}
+
+// TxExecutor is implemented by drivers able to execute statements in a
+// transaction, used by WithTransaction.
+type TxExecutor interface {
+	// ExecuteTx executes statements in a transaction, committed if they all
+	// succeed, and rolled back otherwise.
+	ExecuteTx(ctx context.Context, statements []string) error
+}
+
+// ContextExecutor is implemented by drivers able to cancel the execution
+// of a statement with a context, used to enforce WithJobTimeout.
+type ContextExecutor interface {
+	ExecuteContext(ctx context.Context, statement string) error
+}
+
+// ResultExecutor is implemented by drivers able to report the number of
+// rows affected by a statement, recorded in Run.RowsAffected.
+type ResultExecutor interface {
+	ExecuteResult(ctx context.Context, statement string) (rowsAffected int64, err error)
+}
+
+// executeContext executes statement with d.ExecuteResult if the driver is
+// a ResultExecutor, returning the rows affected, with d.ExecuteContext if
+// it is a ContextExecutor, or with d.Execute otherwise, which can't be cancelled.
+func executeContext(ctx context.Context, d driver.Driver, statement string) (int64, error) {
+	switch d := d.(type) {
+	case ResultExecutor:
+		return d.ExecuteResult(ctx, statement)
+	case ContextExecutor:
+		return 0, d.ExecuteContext(ctx, statement)
+	}
+	return 0, d.Execute(statement)
+}
+
+// retry is the retry policy of failed jobs
+type retry struct {
+	attempts int
+	base     time.Duration
+	max      time.Duration
+}
+
+// delay returns the backoff before the attempt following the given one:
+// base, doubled on each attempt, up to max.
+func (r retry) delay(attempt int) time.Duration {
+	d := r.base
+	for i := 1; i < attempt && (r.max <= 0 || d < r.max); i++ {
+		d *= 2
+	}
+	if r.max > 0 && d > r.max {
+		d = r.max
+	}
+	return d
+}
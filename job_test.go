@@ -0,0 +1,150 @@
+package cronjobs_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	_ "time/tzdata" // for America/New_York, whatever the system
+
+	"github.com/db-journey/cronjobs"
+	"github.com/db-journey/cronjobs/cronjobstest"
+)
+
+func TestPanicRecovered(t *testing.T) {
+	d := &cronjobstest.FakeDriver{}
+	s := newScheduler(t, d)
+	if _, err := s.AddJob("panics", "@yearly", func(context.Context) error { panic("boom") }); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddReader("other", "@yearly", strings.NewReader("DELETE FROM sessions")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Trigger("panics"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LastError("panics"); err == nil || !strings.Contains(err.Error(), "panic: boom") {
+		t.Errorf("panicking job error = %v, want the panic", err)
+	}
+	if err := s.Trigger("other"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LastError("other"); err != nil {
+		t.Errorf("other job error = %v", err)
+	}
+	if n := d.CountContaining("DELETE FROM sessions"); n != 1 {
+		t.Errorf("other job executed %d times, want 1", n)
+	}
+}
+
+func TestNextNTimeZone(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 07:00 in New York, the day before the switch to daylight saving time
+	now := time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)
+	want := []time.Time{
+		time.Date(2024, 3, 9, 14, 0, 0, 0, time.UTC),  // 09:00 EST
+		time.Date(2024, 3, 10, 13, 0, 0, 0, time.UTC), // 09:00 EDT
+	}
+
+	tests := []struct {
+		name string
+		spec string
+		opts []cronjobs.Option
+	}{
+		{"CRON_TZ", "CRON_TZ=America/New_York 0 9 * * *", nil},
+		{"WithLocation", "0 9 * * *", []cronjobs.Option{cronjobs.WithLocation(ny)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]cronjobs.Option{cronjobs.WithClock(newFakeClock(now))}, tt.opts...)
+			s := newScheduler(t, &cronjobstest.FakeDriver{}, opts...)
+			if err := s.AddReader("report", tt.spec, strings.NewReader("SELECT 1")); err != nil {
+				t.Fatal(err)
+			}
+			times, err := s.NextN("report", len(want))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(times) != len(want) {
+				t.Fatalf("NextN = %v, want %v", times, want)
+			}
+			for i := range want {
+				if !times[i].Equal(want[i]) {
+					t.Errorf("NextN[%d] = %v, want %v", i, times[i].UTC(), want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestStopSkipsWaitingJobs(t *testing.T) {
+	d := &cronjobstest.FakeDriver{}
+	s := newScheduler(t, d, cronjobs.WithMaxConcurrency(1))
+	started, release := make(chan struct{}), make(chan struct{})
+	if _, err := s.AddJob("slow", "@yearly", func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"a", "b", "c", "d"}
+	for _, name := range names {
+		if err := s.AddReader(name, "@yearly", strings.NewReader("SELECT "+name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	trigger := func(name string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Trigger(name)
+		}()
+	}
+	trigger("slow")
+	<-started
+	for _, name := range names {
+		trigger(name)
+	}
+	time.Sleep(50 * time.Millisecond) // for the jobs to wait for the slot
+
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not return")
+	}
+	wg.Wait()
+
+	if n := d.Count(); n != 0 {
+		t.Errorf("%d waiting jobs executed after Stop, want 0: %q", n, d.Statements())
+	}
+	for _, name := range names {
+		if err := s.LastError(name); err != nil {
+			t.Errorf("job %q error = %v, want none", name, err)
+		}
+		if run, ok := s.LastRun(name); ok && !errors.Is(run.Error, cronjobs.ErrSkippedStopping) {
+			t.Errorf("job %q run error = %v, want ErrSkippedStopping", name, run.Error)
+		}
+	}
+}
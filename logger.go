@@ -0,0 +1,73 @@
+package cronjobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+)
+
+// RunLogger logs job runs.
+// The scheduler calls LogRun with each Run, one at a time.
+type RunLogger interface {
+	LogRun(*Run)
+}
+
+// LoggerFunc is a function used as a RunLogger
+type LoggerFunc func(*Run)
+
+// LogRun calls f(run)
+func (f LoggerFunc) LogRun(run *Run) {
+	f(run)
+}
+
+// StdoutLogger is the default RunLogger, printing a simple status on stdout
+type StdoutLogger struct{}
+
+// LogRun prints the run status on stdout
+func (StdoutLogger) LogRun(run *Run) {
+	fmt.Printf("Running %s (run %d): ", run.Name, run.ID)
+	if run.Error != nil {
+		fmt.Printf("error=%s\n", run.Error)
+	} else if run.DryRun {
+		fmt.Printf("OK (dry run)\n")
+	} else if run.RowsAffected != nil {
+		fmt.Printf("OK rows=%d\n", *run.RowsAffected)
+	} else {
+		fmt.Printf("OK\n")
+	}
+}
+
+// slogLogger is a RunLogger writing runs to a slog.Logger
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (l slogLogger) LogRun(run *Run) {
+	args := []interface{}{"job", run.Name, "run_id", run.ID, "duration", run.Duration}
+	if run.RowsAffected != nil {
+		args = append(args, "rows_affected", *run.RowsAffected)
+	}
+	if run.DryRun {
+		args = append(args, "dry_run", true)
+	}
+	if run.Error != nil {
+		l.l.Error("cronjob run failed", append(args, "error", run.Error)...)
+		return
+	}
+	level := slog.LevelInfo
+	if run.logLevel != nil {
+		level = *run.logLevel
+	}
+	l.l.Log(context.Background(), level, "cronjob run", args...)
+}
+
+// warn logs a problem of the scheduler itself, not related to a run:
+// to the WithLogger logger if any, or with the log package.
+func (s *Scheduler) warn(msg string, args ...interface{}) {
+	if s.slog != nil {
+		s.slog.Warn("cronjobs: "+msg, args...)
+		return
+	}
+	log.Println(append([]interface{}{"cronjobs: " + msg}, args...)...)
+}
@@ -0,0 +1,31 @@
+package cronjobs
+
+import "time"
+
+// window is a daily maintenance window, from start to end after midnight.
+// It spans midnight when end is before start.
+type window struct {
+	start, end time.Duration
+}
+
+// contains reports whether the time of day of t is in the window
+func (w window) contains(t time.Time) bool {
+	y, m, d := t.Date()
+	since := t.Sub(time.Date(y, m, d, 0, 0, 0, 0, t.Location()))
+	if w.start <= w.end {
+		return since >= w.start && since < w.end
+	}
+	return since >= w.start || since < w.end
+}
+
+// inMaintenance reports whether t is in one of the maintenance windows,
+// in the scheduler location.
+func (s *Scheduler) inMaintenance(t time.Time) bool {
+	t = t.In(s.location)
+	for _, w := range s.windows {
+		if w.contains(t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package cronjobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// manifestEntry is a job of a manifest
+type manifestEntry struct {
+	Name string `json:"name"`
+	// Spec can be followed by metadata, as on spec lines.
+	// When empty, the specs are read from the body.
+	Spec string `json:"spec"`
+	Body string `json:"body"`
+	// File, relative to the manifest, holds the body when Body is empty
+	File string `json:"file"`
+}
+
+// ReadManifest registers the jobs listed in the JSON manifest at fPath,
+// as an alternative to a file per job:
+//
+//	[
+//		{"name": "cleanup", "spec": "@hourly", "body": "DELETE FROM sessions WHERE expired"},
+//		{"name": "rollup", "spec": "0 3 * * * desc=\"refresh sales\"", "file": "sql/rollup.sql"}
+//	]
+//
+// Every job must have a name, a valid spec and a non-empty body: if one of
+// them fails to load, no job is registered, and the failures are reported
+// in a FileErrors.
+func (s *Scheduler) ReadManifest(fPath string) error {
+	data, err := os.ReadFile(fPath)
+	if err != nil {
+		return err
+	}
+	var entries []manifestEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fileError(fPath, err)
+	}
+
+	var all []*job
+	var errs FileErrors
+	names := make(map[string]bool)
+	for _, entry := range entries {
+		jobs, err := s.manifestJobs(filepath.Dir(fPath), fPath, entry)
+		for _, j := range jobs {
+			if names[j.name] {
+				err = fmt.Errorf("duplicate job name %q", j.name)
+			}
+			names[j.name] = true
+		}
+		if err != nil {
+			errs = append(errs, fileError(fPath, fmt.Errorf("job %q: %w", entry.Name, err)))
+			continue
+		}
+		all = append(all, jobs...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	if err := s.register(all, ""); err != nil {
+		return fileError(fPath, err)
+	}
+	return nil
+}
+
+// manifestJobs returns the jobs of a manifest entry, from the manifest at
+// fPath in dir.
+func (s *Scheduler) manifestJobs(dir, fPath string, entry manifestEntry) ([]*job, error) {
+	if entry.Name == "" {
+		return nil, errors.New("missing name")
+	}
+	content := entry.Body
+	if content == "" && entry.File != "" {
+		fPath = filepath.Join(dir, filepath.FromSlash(entry.File))
+		data, err := os.ReadFile(fPath)
+		if err != nil {
+			return nil, err
+		}
+		content = string(data)
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, errors.New("empty body")
+	}
+	specs := []specLine{newSpecLine(entry.Spec, 0)}
+	if entry.Spec == "" {
+		var err error
+		if specs, err = s.parseSpecs(content); err != nil {
+			return nil, err
+		}
+	}
+	jobs, err := s.parseJobs(entry.Name, fPath, content, specs)
+	absPath, _ := filepath.Abs(fPath)
+	for _, j := range jobs {
+		j.absPath = absPath
+	}
+	return jobs, err
+}
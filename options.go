@@ -0,0 +1,411 @@
+package cronjobs
+
+import (
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/db-journey/migrate/v2/driver"
+	"github.com/robfig/cron/v3"
+)
+
+// Option configures a Scheduler created by New
+type Option func(*Scheduler)
+
+// WithMaxConcurrency limits the number of jobs executing at the same time.
+// Jobs firing while n jobs are executing wait for a slot to be freed,
+// given to the waiting job with the highest priority= metadata first,
+// and to the first one waiting for the same priority.
+// There is no limit when n <= 0, the default.
+// It can't be combined with WithSequential.
+func WithMaxConcurrency(n int) Option {
+	return func(s *Scheduler) {
+		s.maxConcurrency = n
+	}
+}
+
+// WithRetry retries failed jobs, up to a total of attempts runs.
+// The delay between attempts starts at base and doubles each time,
+// without exceeding max (no limit if max is 0).
+func WithRetry(attempts int, base, max time.Duration) Option {
+	return func(s *Scheduler) {
+		s.retry = retry{attempts, base, max}
+	}
+}
+
+// WithJobTimeout cancels jobs running for longer than d.
+// The Run of a cancelled job has an error wrapping context.DeadlineExceeded.
+// Only drivers implementing ContextExecutor can be cancelled:
+// with other drivers, the job runs to completion.
+// A job can override d with the timeout= metadata, ex: timeout=10m,
+// or timeout=0 for no timeout. 0 means no timeout, the default.
+func WithJobTimeout(d time.Duration) Option {
+	return func(s *Scheduler) {
+		s.jobTimeout = d
+	}
+}
+
+// WithDefaultTimeout is WithJobTimeout: d is the timeout of the jobs
+// without a timeout= metadata.
+func WithDefaultTimeout(d time.Duration) Option {
+	return WithJobTimeout(d)
+}
+
+// WithHardTimeout stops waiting for jobs running for longer than d, even
+// with drivers ignoring the cancellation of WithJobTimeout: the Run of the
+// job fails with ErrHardTimeout, and its WithMaxConcurrency slot is freed.
+//
+// The execution itself can't be stopped, and keeps running in the background
+// for as long as the driver takes, holding its database connection: the
+// abandoned executions are never waited for, by Stop either, and the next
+// run of the job may overlap with them. d should be longer than the
+// WithJobTimeout, so that cooperative drivers are cancelled first.
+func WithHardTimeout(d time.Duration) Option {
+	return func(s *Scheduler) {
+		s.hardTimeout = d
+	}
+}
+
+// WithObserver calls observe with each Run, synchronously from the job
+// that produced it, before it is sent to the Logger and the Runs channels.
+// It is meant for fast bookkeeping, like updating metrics: observe must
+// be safe for concurrent use, and should not block.
+func WithObserver(observe func(*Run)) Option {
+	return func(s *Scheduler) {
+		s.observers = append(s.observers, observe)
+	}
+}
+
+// WithEventObserver calls observe with each lifecycle Event of the
+// scheduler: when it starts, stops, is paused or resumed, when jobs are
+// reloaded, and when jobs are added or removed.
+// observe is called once the scheduler is unlocked, so that it can use it,
+// and should not block.
+func WithEventObserver(observe func(Event)) Option {
+	return func(s *Scheduler) {
+		s.eventObservers = append(s.eventObservers, observe)
+	}
+}
+
+// WithLogger replaces the default stdout Logger with one logging
+// a structured record per run to l: at Info level for successful runs,
+// at Error level for failed ones. The level of the successful runs of a
+// job can be set with the logLevel= metadata: "-- cron: @hourly logLevel=debug"
+func WithLogger(l *slog.Logger) Option {
+	return func(s *Scheduler) {
+		s.Logger = slogLogger{l}
+		s.slog = l
+	}
+}
+
+// WithBufferSize sets the size of the runs channel buffer, 128 by default.
+// When the buffer is full, jobs block until the Logger is done with a Run,
+// so that 0 makes each job wait for its Run to be handled.
+func WithBufferSize(n int) Option {
+	return func(s *Scheduler) {
+		s.bufferSize = n
+	}
+}
+
+// WithFullPolicy sets what happens to runs when the runs channel is full.
+// With DropNewest, runs are dropped rather than blocking jobs, and counted
+// by Dropped. Observers still see every run.
+func WithFullPolicy(policy FullPolicy) Option {
+	return func(s *Scheduler) {
+		s.fullPolicy = policy
+	}
+}
+
+// WithLocation sets the time zone in which specs are run, time.Local by default.
+// Specs prefixed by CRON_TZ= use their own time zone instead.
+func WithLocation(loc *time.Location) Option {
+	return func(s *Scheduler) {
+		s.cronOpts = append(s.cronOpts, cron.WithLocation(loc))
+		s.location = loc
+	}
+}
+
+// WithSeconds makes specs start with a seconds field, for 6 fields in total:
+// "*/30 * * * * *" is every 30 seconds.
+func WithSeconds() Option {
+	return func(s *Scheduler) {
+		s.seconds = true
+	}
+}
+
+// WithHistorySize sets the number of runs kept per job, and returned by
+// History, 10 by default. 0 disables the history.
+func WithHistorySize(n int) Option {
+	return func(s *Scheduler) {
+		s.history.size = n
+	}
+}
+
+// WithRunPersistence records each run in the table of the database,
+// through the driver, with the run ID (see Run.ID), the job name, the start
+// time, the duration in milliseconds, and the error if any. The table is
+// created when the scheduler starts, if it does not exist:
+//
+//	CREATE TABLE IF NOT EXISTS <table> (
+//		run_id BIGINT NOT NULL,
+//		job VARCHAR(255) NOT NULL,
+//		started_at TIMESTAMP NOT NULL,
+//		duration_ms BIGINT NOT NULL,
+//		error TEXT
+//	)
+//
+// The start time is recorded in UTC. A table created without the run_id
+// column needs it added: ALTER TABLE <table> ADD COLUMN run_id BIGINT.
+// Runs are recorded in the background, like OnSuccess and OnFailure callbacks:
+// a failure to record a run is logged, and doesn't fail the run.
+func WithRunPersistence(table string) Option {
+	return func(s *Scheduler) {
+		s.persistence = &persistence{table}
+	}
+}
+
+// WithJobLocks makes the scheduler take a lock through the driver, which
+// must be a JobLocker, before running a job: so that when several instances
+// of the scheduler run the same jobs, only one of them runs a job at a time.
+// The other ones skip the job, with a Run failing with ErrLockHeld.
+// The lock of a job is named "cronjobs:<job name>".
+func WithJobLocks() Option {
+	return func(s *Scheduler) {
+		s.locks = true
+	}
+}
+
+// WithTemplate renders job bodies as text/template templates, executed with
+// data, before they are run. The spec lines are left out of the templates.
+// Templates are rendered once, when jobs are loaded, so that errors are
+// reported by ReadFiles. They can use the env function to read an
+// environment variable: {{ env "SCHEMA" }}
+func WithTemplate(data interface{}) Option {
+	return func(s *Scheduler) {
+		s.templating = &templating{data: func() interface{} { return data }, static: true}
+	}
+}
+
+// WithTemplateFunc is like WithTemplate, with the data returned by data
+// for each run: templates are parsed when jobs are loaded, but rendered
+// before each run, a failure to render failing the run.
+func WithTemplateFunc(data func() interface{}) Option {
+	return func(s *Scheduler) {
+		s.templating = &templating{data: data}
+	}
+}
+
+// envExpansion is the expansion of environment variables in job bodies
+type envExpansion int
+
+const (
+	noExpansion envExpansion = iota
+	lenientExpansion
+	strictExpansion
+)
+
+// WithEnvExpansion replaces the ${VAR} and $VAR references to environment
+// variables in job bodies, before each run. Spec lines are left as is, and
+// so are "$1" and "$$", which are not valid variable names. References to
+// unset variables are left as is.
+func WithEnvExpansion() Option {
+	return func(s *Scheduler) {
+		s.envExpansion = lenientExpansion
+	}
+}
+
+// WithStrictEnvExpansion is like WithEnvExpansion, failing the runs
+// of jobs referencing unset variables.
+func WithStrictEnvExpansion() Option {
+	return func(s *Scheduler) {
+		s.envExpansion = strictExpansion
+	}
+}
+
+// WithDriver adds a driver, used by the jobs with the driver=<name> metadata
+// instead of the default driver given to New:
+// "-- cron: @daily driver=analytics"
+// It panics if drv is nil.
+func WithDriver(name string, drv driver.Driver) Option {
+	if drv == nil {
+		panic("cronjobs: WithDriver called with a nil driver for " + name)
+	}
+	return func(s *Scheduler) {
+		if s.drivers == nil {
+			s.drivers = make(map[string]driver.Driver)
+		}
+		s.drivers[name] = drv
+	}
+}
+
+// WithStatementSplit executes job bodies one statement at a time, for the
+// drivers unable to execute several statements at once. Statements are
+// split on ";", except in strings and comments, leaving out the spec lines.
+// The first failing statement stops the run, its error telling which
+// statement failed, counting from 1.
+func WithStatementSplit() Option {
+	return func(s *Scheduler) {
+		s.split = true
+	}
+}
+
+// WithTransaction runs each job in a transaction, committed if the job
+// succeeds and rolled back otherwise, for drivers implementing TxExecutor.
+// With other drivers, jobs are executed without a transaction, as noted
+// in a warning when the scheduler starts.
+func WithTransaction() Option {
+	return func(s *Scheduler) {
+		s.tx = true
+	}
+}
+
+// WithDryRun schedules the jobs without executing them: their runs are
+// reported, with Run.DryRun set, but the drivers are never called,
+// for job locks, runs persistence or WithCatchUp either.
+func WithDryRun() Option {
+	return func(s *Scheduler) {
+		s.dryRun = true
+	}
+}
+
+// WithJitter delays each scheduled run by a random duration up to max,
+// to spread the jobs firing at the same time. The delay is not part of
+// the run duration, and does not apply to Trigger.
+// A job can override max with the jitter= metadata.
+func WithJitter(max time.Duration) Option {
+	return func(s *Scheduler) {
+		s.jitter = max
+	}
+}
+
+// WithMaintenanceWindow adds a daily maintenance window, from start to end
+// after midnight in the scheduler location (see WithLocation):
+// the jobs firing during the window are skipped, with ErrMaintenanceWindow.
+// The window spans midnight when end is before start.
+//
+//	cronjobs.WithMaintenanceWindow(1*time.Hour, 2*time.Hour) // 01:00 to 02:00
+func WithMaintenanceWindow(start, end time.Duration) Option {
+	return func(s *Scheduler) {
+		s.windows = append(s.windows, window{start, end})
+	}
+}
+
+// WithCatchUp makes Start run once, right away, the jobs which missed a
+// run while the scheduler was down: the jobs which should have fired since
+// their last successful run recorded by WithRunPersistence, which is required.
+// The driver must be a TimeQuerier, to read the runs table.
+// Only use it when all the jobs are safe to run late.
+// Nothing is caught up with WithDryRun, as the runs table is not read.
+func WithCatchUp() Option {
+	return func(s *Scheduler) {
+		s.catchUp = true
+	}
+}
+
+// WithClock replaces the wall clock used for the run times and durations,
+// and for the delays of retries and jitter, with c: see Clock, and Tick
+// to fire the jobs on the time of c.
+func WithClock(c Clock) Option {
+	return func(s *Scheduler) {
+		s.clock = c
+	}
+}
+
+// WithSpecOverrides replaces the specs of the jobs loaded from files with
+// the ones of overrides, by job name, so that schedules can be changed
+// without changing the files: see ReadSpecOverrides.
+// The overrides of jobs not found by ReadFiles are reported with a warning.
+func WithSpecOverrides(overrides map[string]string) Option {
+	return func(s *Scheduler) {
+		s.overrides = overrides
+	}
+}
+
+// WithStrict sets whether ReadFiles, ReadFS and Validate stop at the first
+// file failing to load, registering no job, instead of loading all the
+// files they can, which is the default: see ReadFiles.
+func WithStrict(strict bool) Option {
+	return func(s *Scheduler) {
+		s.strict = strict
+	}
+}
+
+// WithRedactor applies redact to the error messages of the runs before
+// they are output: by the Logger, the runs persistence, and the Handler.
+// The runs sent to the observers, the callbacks and the Runs channels keep
+// their errors as is, and the redacted errors still wrap them, for errors.Is.
+func WithRedactor(redact func(string) string) Option {
+	return func(s *Scheduler) {
+		s.redactor = redact
+	}
+}
+
+// WithRedactPatterns is WithRedactor, replacing the matches of patterns
+// with "[REDACTED]".
+func WithRedactPatterns(patterns ...*regexp.Regexp) Option {
+	return WithRedactor(redactPatterns(patterns))
+}
+
+// WithCronOptions passes opts to cron.New, like cron.WithChain to wrap the
+// jobs, or cron.WithLogger.
+// The package parses the specs itself, when jobs are loaded: cron.WithParser
+// and cron.WithSeconds are overridden, use WithSeconds instead, and
+// cron.WithLocation does not apply to maintenance windows or NextN,
+// unlike WithLocation.
+func WithCronOptions(opts ...cron.Option) Option {
+	return func(s *Scheduler) {
+		s.cronOpts = append(s.cronOpts, opts...)
+	}
+}
+
+// WithDuplicateBodyCheck reports the job files with the same statements
+// as another one, spec lines and surrounding blanks aside, when they are
+// loaded: with a warning naming both files, or as the file error with
+// WithStrict(true).
+func WithDuplicateBodyCheck() Option {
+	return func(s *Scheduler) {
+		s.dupBodies = true
+	}
+}
+
+// WithSequential executes the jobs one at a time, in the order they fire:
+// the jobs firing while another one executes wait their turn, in FIFO order
+// whatever their priority= metadata, and then run and are reported as usual.
+// It is stronger than WithMaxConcurrency(1), which lets the jobs of higher
+// priority go first, and New panics if both are used.
+func WithSequential() Option {
+	return func(s *Scheduler) {
+		s.sequential = true
+	}
+}
+
+// WithRequireJobs makes Start fail with ErrNoJobs when no job is registered,
+// like when ReadFiles was given the wrong directory, instead of logging it.
+func WithRequireJobs() Option {
+	return func(s *Scheduler) {
+		s.requireJobs = true
+	}
+}
+
+// WithNameFunc derives the names of the jobs loaded from files with name,
+// instead of jobName: name is given the path of the file, relative to the
+// job directory and slash-separated, like "daily/cleanup.sql".
+// Several jobs of a file are still named name#1, name#2...
+func WithNameFunc(name func(path string) string) Option {
+	return func(s *Scheduler) {
+		s.nameFunc = name
+	}
+}
+
+// WithOrdered fires the jobs sharing the exact same spec one after the
+// other, sorted by name, so that with files prefixed by "01_", "02_"...
+// the first one is done before the next one starts. The order only holds
+// for the jobs firing at the same instant: it is not enforced between
+// runs at different times, triggered runs, or the @reboot jobs, and the
+// jitter of a job delays the ones after it. For dependencies between jobs
+// with different specs, see the after= metadata.
+func WithOrdered() Option {
+	return func(s *Scheduler) {
+		s.ordered = true
+	}
+}
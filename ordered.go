@@ -0,0 +1,63 @@
+package cronjobs
+
+import (
+	"sort"
+
+	"github.com/robfig/cron/v3"
+)
+
+// specGroup is the jobs sharing a spec, fired in name order by a single
+// cron entry, see WithOrdered
+type specGroup struct {
+	id   cron.EntryID
+	jobs []*job // sorted by name
+}
+
+// schedule adds the cron entry of j. s.mu must be held.
+func (s *Scheduler) schedule(j *job) {
+	if !s.ordered {
+		j.id = s.Schedule(j.schedule, cron.FuncJob(func() { s.fire(j) }))
+		return
+	}
+	g, ok := s.groups[j.spec]
+	if !ok {
+		g = &specGroup{}
+		g.id = s.Schedule(j.schedule, cron.FuncJob(func() { s.fireGroup(g) }))
+		if s.groups == nil {
+			s.groups = make(map[string]*specGroup)
+		}
+		s.groups[j.spec] = g
+	}
+	i := sort.Search(len(g.jobs), func(i int) bool { return g.jobs[i].name >= j.name })
+	g.jobs = append(g.jobs[:i], append([]*job{j}, g.jobs[i:]...)...)
+	j.id = g.id
+}
+
+// unschedule removes the cron entry of j, if any. s.mu must be held.
+func (s *Scheduler) unschedule(j *job) {
+	g, ok := s.groups[j.spec]
+	if !ok || g.id != j.id {
+		s.Cron.Remove(j.id)
+		return
+	}
+	for i, other := range g.jobs {
+		if other == j {
+			g.jobs = append(g.jobs[:i], g.jobs[i+1:]...)
+			break
+		}
+	}
+	if len(g.jobs) == 0 {
+		s.Cron.Remove(g.id)
+		delete(s.groups, j.spec)
+	}
+}
+
+// fireGroup fires the jobs of g one after the other
+func (s *Scheduler) fireGroup(g *specGroup) {
+	s.mu.Lock()
+	jobs := append([]*job(nil), g.jobs...)
+	s.mu.Unlock()
+	for _, j := range jobs {
+		s.fire(j)
+	}
+}
@@ -0,0 +1,48 @@
+package cronjobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ReadSpecOverrides reads a JSON file mapping job names to cron specs,
+// for WithSpecOverrides:
+//
+//	{"nightly-rollup": "0 3 * * *", "cleanup": "@every 2h"}
+func ReadSpecOverrides(fPath string) (map[string]string, error) {
+	data, err := os.ReadFile(fPath)
+	if err != nil {
+		return nil, err
+	}
+	var overrides map[string]string
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("spec overrides %s: %w", fPath, err)
+	}
+	return overrides, nil
+}
+
+// override replaces the spec of j by spec
+func (s *Scheduler) override(j *job, spec string) error {
+	j.spec, j.schedule, j.atStart = spec, nil, spec == rebootSpec
+	if j.atStart {
+		return nil
+	}
+	schedule, err := s.parser.Parse(spec)
+	if err != nil {
+		return fmt.Errorf("spec override of %q: %w", j.name, &SpecError{Spec: spec, format: s.specFormat(), Err: err})
+	}
+	j.schedule = schedule
+	return nil
+}
+
+// checkOverrides warns about the spec overrides of jobs which are not registered
+func (s *Scheduler) checkOverrides() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name := range s.overrides {
+		if _, ok := s.jobs[name]; !ok {
+			s.warn("spec override of an unknown job", "job", name)
+		}
+	}
+}
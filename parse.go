@@ -0,0 +1,244 @@
+package cronjobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	cronRE = regexp.MustCompile(`^.*cron:[ \t]+(.*)$`)
+	// commentCronRE only matches a spec line starting with comment chars,
+	// not the "cron:" of a string literal: "select 'cron: x'"
+	commentCronRE = regexp.MustCompile(`^\s*[^\w\s'"]+\s*cron:[ \t]+(.*)$`)
+)
+
+// specLine is a cron spec, found at line of its file (1-based, 0 if unknown),
+// followed by the metadata of its job.
+type specLine struct {
+	spec string
+	line int
+	end  int // last line of a front-matter block starting at line, if more than one
+	meta map[string]string
+}
+
+// lines returns the lines of the spec in its file
+func (sl specLine) lines() []int {
+	if sl.line == 0 {
+		return nil
+	}
+	lines := []int{sl.line}
+	for l := sl.line + 1; l <= sl.end; l++ {
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+// newSpecLine splits the text following "cron:" into the spec and the
+// optional key=value fields after it, the value possibly double-quoted:
+// "@daily name=nightly-rollup desc="refresh sales" tags=finance,reporting"
+// The spec ends at the first key=value field, except for a leading
+// CRON_TZ= or TZ= time zone, which is part of the spec.
+func newSpecLine(text string, line int) specLine {
+	sl := specLine{line: line}
+	fields := splitFields(text)
+	var spec []string
+	for i, f := range fields {
+		key, value, ok := strings.Cut(f, "=")
+		if !ok || (i == 0 && (key == "CRON_TZ" || key == "TZ")) {
+			if sl.meta != nil {
+				continue // stray word among the metadata
+			}
+			spec = append(spec, f)
+			continue
+		}
+		if sl.meta == nil {
+			sl.meta = make(map[string]string)
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		sl.meta[key] = value
+	}
+	sl.spec = strings.Join(spec, " ")
+	// a duration may be written with blanks: "@every 1h 30m"
+	if before, after, ok := strings.Cut(sl.spec, "@every "); ok {
+		sl.spec = before + "@every " + strings.Join(strings.Fields(after), "")
+	}
+	return sl
+}
+
+// splitFields splits text around blanks, except within double quotes
+func splitFields(text string) []string {
+	var fields []string
+	var field strings.Builder
+	quoted := false
+	for _, r := range text {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			field.WriteRune(r)
+		case (r == ' ' || r == '\t') && !quoted:
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+		default:
+			field.WriteRune(r)
+		}
+	}
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
+
+// parseSpecs returns the cron specs of the header lines of content.
+// The header is made of the first consecutive lines holding a spec: each
+// spec line is matched on its own, so that two spec lines can't be merged
+// in one spec. The first line of the file can start with anything before
+// "cron:", other lines only with comment chars.
+// With StrictFirstLine, the header must start at the first line.
+// With CommentPrefixes, all the spec lines must start with one of them.
+// A front-matter block at the top of content is used instead, when present.
+func (s *Scheduler) parseSpecs(content string) ([]specLine, error) {
+	if specs, ok, err := parseFrontMatter(content); ok {
+		return specs, err
+	}
+	lines := strings.Split(content, "\n")
+	prefixRE := s.prefixRE()
+
+	var specs []specLine
+	for i, line := range lines {
+		re := commentCronRE
+		if prefixRE != nil {
+			re = prefixRE
+		} else if i == 0 || s.StrictFirstLine {
+			re = cronRE
+		}
+		match := re.FindStringSubmatch(line)
+		if match == nil {
+			if len(specs) > 0 || s.StrictFirstLine {
+				break
+			}
+			continue
+		}
+		// trim the "\r" of CRLF line endings, and surrounding blanks
+		specs = append(specs, newSpecLine(strings.TrimSpace(match[1]), i+1))
+	}
+	return specs, nil
+}
+
+// prefixRE returns the regexp matching the spec lines starting with one of
+// the CommentPrefixes, or nil if they are not set.
+func (s *Scheduler) prefixRE() *regexp.Regexp {
+	if len(s.CommentPrefixes) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(s.CommentPrefixes))
+	for i, prefix := range s.CommentPrefixes {
+		quoted[i] = regexp.QuoteMeta(prefix)
+	}
+	return regexp.MustCompile(`^\s*(?:` + strings.Join(quoted, "|") + `)\s*cron:[ \t]+(.*)$`)
+}
+
+// frontMatterPrefix starts a front-matter block, ended by "*/"
+const frontMatterPrefix = "/* cronjobs:"
+
+// parseFrontMatter parses the front-matter block at the top of content,
+// if any, a JSON object holding the spec and the metadata of the job:
+//
+//	/* cronjobs: {"spec": "@daily", "tags": ["finance", "reporting"], "timeout": "5m"} */
+//
+// spec can also be a list, for several specs. The other values are used
+// as the ones of key=value metadata, the lists being joined with ",".
+func parseFrontMatter(content string) ([]specLine, bool, error) {
+	trimmed := strings.TrimLeft(content, " \t\r\n")
+	if !strings.HasPrefix(trimmed, frontMatterPrefix) {
+		return nil, false, nil
+	}
+	line := strings.Count(content[:len(content)-len(trimmed)], "\n") + 1
+	block, _, ok := strings.Cut(trimmed[len(frontMatterPrefix):], "*/")
+	end := line + strings.Count(block, "\n")
+	if !ok {
+		return nil, true, &metaError{line, "cronjobs", "", errors.New("front matter not closed by */")}
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(block), &fields); err != nil {
+		return nil, true, &metaError{line, "cronjobs", strings.TrimSpace(block), err}
+	}
+
+	meta := make(map[string]string)
+	var specs []string
+	for key, value := range fields {
+		var values []string
+		switch v := value.(type) {
+		case []interface{}:
+			for _, e := range v {
+				values = append(values, fmt.Sprint(e))
+			}
+		default:
+			values = []string{fmt.Sprint(v)}
+		}
+		if key == "spec" {
+			specs = values
+			continue
+		}
+		meta[key] = strings.Join(values, ",")
+	}
+	lines := make([]specLine, len(specs))
+	for i, spec := range specs {
+		lines[i] = specLine{spec: spec, line: line, end: end, meta: meta}
+	}
+	return lines, true, nil
+}
+
+// boolMeta returns the boolean value of the metadata key, or def when not set
+func (sl specLine) boolMeta(key string, def bool) (bool, error) {
+	value, ok := sl.meta[key]
+	if !ok {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return def, &metaError{sl.line, key, value, err}
+	}
+	return b, nil
+}
+
+// durationMeta returns the duration value of the metadata key, or def when not set
+func (sl specLine) durationMeta(key string, def time.Duration) (time.Duration, error) {
+	value, ok := sl.meta[key]
+	if !ok {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return def, &metaError{sl.line, key, value, err}
+	}
+	return d, nil
+}
+
+// priorities are the named values of the priority= metadata
+var priorities = map[string]int{"low": -1, "normal": 0, "high": 1}
+
+// priorityMeta returns the value of the priority= metadata, a name of
+// priorities or an integer, 0 when not set.
+func (sl specLine) priorityMeta() (int, error) {
+	value, ok := sl.meta["priority"]
+	if !ok {
+		return 0, nil
+	}
+	if p, ok := priorities[value]; ok {
+		return p, nil
+	}
+	p, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &metaError{sl.line, "priority", value, errors.New("not low, normal, high, or an integer")}
+	}
+	return p, nil
+}
@@ -0,0 +1,81 @@
+package cronjobs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewSpecLine(t *testing.T) {
+	tests := []struct {
+		text string
+		spec string
+		meta map[string]string
+	}{
+		{"@daily", "@daily", nil},
+		{"0\t3  * * *", "0 3 * * *", nil},
+		{
+			`@daily name=nightly desc="refresh sales" tags=finance,reporting`,
+			"@daily",
+			map[string]string{"name": "nightly", "desc": "refresh sales", "tags": "finance,reporting"},
+		},
+		{"CRON_TZ=America/New_York 0 9 * * * name=report", "CRON_TZ=America/New_York 0 9 * * *", map[string]string{"name": "report"}},
+		{"TZ=UTC @hourly", "TZ=UTC @hourly", nil},
+		{"@every 1h 30m desc=slow", "@every 1h30m", map[string]string{"desc": "slow"}},
+		{"@daily name=a stray", "@daily", map[string]string{"name": "a"}},
+		{`@daily desc="a=b c"`, "@daily", map[string]string{"desc": "a=b c"}},
+		{`@daily desc="unclosed`, "@daily", map[string]string{"desc": `"unclosed`}},
+		{"after=ingest", "", map[string]string{"after": "ingest"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			sl := newSpecLine(tt.text, 1)
+			if sl.spec != tt.spec || !reflect.DeepEqual(sl.meta, tt.meta) {
+				t.Errorf("newSpecLine(%q) = %q %q, want %q %q", tt.text, sl.spec, sl.meta, tt.spec, tt.meta)
+			}
+		})
+	}
+}
+
+func TestParseFrontMatter(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		found   bool
+		specs   []specLine
+		wantErr bool
+	}{
+		{"none", "-- cron: @daily\nSELECT 1", false, nil, false},
+		{"not at the top", "SELECT 1;\n/* cronjobs: {\"spec\": \"@daily\"} */", false, nil, false},
+		{
+			"spec and metadata",
+			`/* cronjobs: {"spec": "@daily", "tags": ["finance", "reporting"], "timeout": "5m", "enabled": false} */`,
+			true,
+			[]specLine{{spec: "@daily", line: 1, end: 1, meta: map[string]string{"tags": "finance,reporting", "timeout": "5m", "enabled": "false"}}},
+			false,
+		},
+		{
+			"several specs, after blank lines, on several lines",
+			"\n\n/* cronjobs: {\n\"spec\": [\"@daily\", \"@hourly\"],\n\"priority\": 1\n} */\nSELECT 1",
+			true,
+			[]specLine{
+				{spec: "@daily", line: 3, end: 6, meta: map[string]string{"priority": "1"}},
+				{spec: "@hourly", line: 3, end: 6, meta: map[string]string{"priority": "1"}},
+			},
+			false,
+		},
+		{"no spec", `/* cronjobs: {"desc": "x"} */`, true, []specLine{}, false},
+		{"not closed", `/* cronjobs: {"spec": "@daily"}`, true, nil, true},
+		{"invalid JSON", `/* cronjobs: {spec: @daily} */`, true, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specs, found, err := parseFrontMatter(tt.content)
+			if found != tt.found || (err != nil) != tt.wantErr {
+				t.Fatalf("found %v, error %v: want %v, error %v", found, err, tt.found, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(specs, tt.specs) {
+				t.Errorf("specs = %+v, want %+v", specs, tt.specs)
+			}
+		})
+	}
+}
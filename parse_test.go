@@ -0,0 +1,72 @@
+package cronjobs_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/db-journey/cronjobs"
+	"github.com/db-journey/cronjobs/cronjobstest"
+)
+
+func TestCRLF(t *testing.T) {
+	s := newScheduler(t, &cronjobstest.FakeDriver{})
+	if err := s.ReadFile("testdata/crlf.sql"); err != nil {
+		t.Fatal(err)
+	}
+	jobs := s.List()
+	if len(jobs) != 1 {
+		t.Fatalf("got %d jobs, want 1", len(jobs))
+	}
+	if jobs[0].Spec != "@daily" || jobs[0].Description != "saved on Windows" {
+		t.Errorf("spec %q, desc %q: want %q, %q", jobs[0].Spec, jobs[0].Description, "@daily", "saved on Windows")
+	}
+}
+
+func TestNoTrailingNewline(t *testing.T) {
+	s := newScheduler(t, &cronjobstest.FakeDriver{})
+	if err := s.ReadFiles("testdata/eof"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"oneline": "@hourly", "last": "@daily"}
+	jobs := s.List()
+	if len(jobs) != len(want) {
+		t.Fatalf("got %d jobs, want %d", len(jobs), len(want))
+	}
+	for _, j := range jobs {
+		if j.Spec != want[j.Name] {
+			t.Errorf("job %q spec = %q, want %q", j.Name, j.Spec, want[j.Name])
+		}
+	}
+}
+
+func TestEvery(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		line     string
+		spec     string
+		interval time.Duration
+	}{
+		{"-- cron: @every 30s", "@every 30s", 30 * time.Second},
+		{"-- cron: @every 1h 30m desc=\"with blanks\"", "@every 1h30m", 90 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s := newScheduler(t, &cronjobstest.FakeDriver{}, cronjobs.WithClock(newFakeClock(now)))
+			if err := s.AddReader("every", "", strings.NewReader(tt.line+"\nSELECT 1")); err != nil {
+				t.Fatal(err)
+			}
+			if spec := s.List()[0].Spec; spec != tt.spec {
+				t.Errorf("spec = %q, want %q", spec, tt.spec)
+			}
+			times, err := s.NextN("every", 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []time.Time{now.Add(tt.interval), now.Add(2 * tt.interval)}
+			if len(times) != 2 || !times[0].Equal(want[0]) || !times[1].Equal(want[1]) {
+				t.Errorf("NextN = %v, want %v", times, want)
+			}
+		})
+	}
+}
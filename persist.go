@@ -0,0 +1,90 @@
+package cronjobs
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// persistence writes the runs to a table, through the driver
+type persistence struct {
+	table string
+}
+
+// bootstrap creates the runs table, if it does not exist
+func (p *persistence) bootstrap(s *Scheduler) error {
+	return s.driver.Execute(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	run_id BIGINT NOT NULL,
+	job VARCHAR(255) NOT NULL,
+	started_at TIMESTAMP NOT NULL,
+	duration_ms BIGINT NOT NULL,
+	error TEXT
+)`, p.table))
+}
+
+// insert writes run to the runs table.
+// A failure is logged, but doesn't fail the run.
+func (p *persistence) insert(s *Scheduler, run *Run) {
+	run = s.redact(run)
+	errText := "NULL"
+	if run.Error != nil {
+		errText = quote(run.Error.Error())
+	}
+	startedAt := run.StartedAt.UTC()
+	err := s.driver.Execute(fmt.Sprintf(
+		"INSERT INTO %s (run_id, job, started_at, duration_ms, error) VALUES (%d, %s, %s, %d, %s)",
+		p.table,
+		run.ID,
+		quote(run.Name),
+		quote(startedAt.Format("2006-01-02 15:04:05.000")),
+		run.Duration.Milliseconds(),
+		errText,
+	))
+	if err != nil {
+		s.warn("persisting run", "job", run.Name, "error", err)
+	}
+}
+
+// TimeQuerier is implemented by drivers able to run a query returning a
+// single time value, used by WithCatchUp to read the runs table.
+type TimeQuerier interface {
+	// QueryTime returns the value of the single row and column of the query
+	// result, or false when it is NULL, or there is no row.
+	QueryTime(query string) (t time.Time, ok bool, err error)
+}
+
+// lastSuccess returns the start time of the last successful run of the job,
+// or false if it never succeeded.
+func (p *persistence) lastSuccess(q TimeQuerier, job string) (time.Time, bool, error) {
+	return q.QueryTime(fmt.Sprintf(
+		"SELECT MAX(started_at) FROM %s WHERE job = %s AND error IS NULL",
+		p.table,
+		quote(job),
+	))
+}
+
+// missed returns the jobs which missed a run since their last successful
+// run recorded in the runs table: the jobs which never succeeded, or are
+// run at start anyway, are left out. s.mu must be held.
+func (p *persistence) missed(s *Scheduler, q TimeQuerier, now time.Time) []*job {
+	var missed []*job
+	for _, j := range s.jobs {
+		if !j.enabled || j.schedule == nil || j.atStart {
+			continue
+		}
+		last, ok, err := p.lastSuccess(q, j.name)
+		if err != nil {
+			s.warn("reading the last run", "job", j.name, "error", err)
+			continue
+		}
+		if ok && j.schedule.Next(last).Before(now) {
+			missed = append(missed, j)
+		}
+	}
+	return missed
+}
+
+// quote returns the SQL string literal of str
+func quote(str string) string {
+	return "'" + strings.ReplaceAll(str, "'", "''") + "'"
+}
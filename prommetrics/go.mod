@@ -0,0 +1,24 @@
+module github.com/db-journey/cronjobs/prommetrics
+
+go 1.21
+
+require (
+	github.com/db-journey/cronjobs v0.0.0-00010101000000-000000000000
+	github.com/prometheus/client_golang v1.19.1
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/db-journey/migrate/v2 v2.1.1 // indirect
+	github.com/fsnotify/fsnotify v1.7.0 // indirect
+	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/prometheus/common v0.48.0 // indirect
+	github.com/prometheus/procfs v0.12.0 // indirect
+	github.com/robfig/cron/v3 v3.0.0 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
+)
+
+// the cronjobs package of this repository
+replace github.com/db-journey/cronjobs => ../
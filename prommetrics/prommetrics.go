@@ -0,0 +1,49 @@
+// Package prommetrics exports Prometheus metrics of cronjobs runs.
+// It is a module of its own, github.com/db-journey/cronjobs/prommetrics,
+// so that only the users of these metrics depend on the Prometheus client.
+package prommetrics
+
+import (
+	"github.com/db-journey/cronjobs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// WithPrometheus registers the cronjobs metrics in reg, and updates them
+// on each job run:
+//   - cronjobs_runs_total: counter of runs, per job
+//   - cronjobs_failures_total: counter of failed runs, per job, not counting the skipped ones
+//   - cronjobs_run_duration_seconds: histogram of run durations, per job
+//   - cronjobs_running: gauge of the jobs executing, see Scheduler.Running
+//
+// It panics if the metrics can't be registered.
+func WithPrometheus(reg prometheus.Registerer) cronjobs.Option {
+	runs := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cronjobs_runs_total",
+		Help: "Total number of job runs.",
+	}, []string{"job"})
+	failures := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cronjobs_failures_total",
+		Help: "Total number of failed job runs.",
+	}, []string{"job"})
+	durations := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cronjobs_run_duration_seconds",
+		Help:    "Duration of job runs.",
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 10),
+	}, []string{"job"})
+	reg.MustRegister(runs, failures, durations)
+
+	observe := cronjobs.WithObserver(func(run *cronjobs.Run) {
+		runs.WithLabelValues(run.Name).Inc()
+		if run.Failed() {
+			failures.WithLabelValues(run.Name).Inc()
+		}
+		durations.WithLabelValues(run.Name).Observe(run.Duration.Seconds())
+	})
+	return func(s *cronjobs.Scheduler) {
+		reg.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "cronjobs_running",
+			Help: "Number of jobs executing.",
+		}, func() float64 { return float64(s.Running()) }))
+		observe(s)
+	}
+}
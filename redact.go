@@ -0,0 +1,34 @@
+package cronjobs
+
+import "regexp"
+
+// redactedError is an error with a redacted message, wrapping the
+// original error so that errors.Is and errors.As still see it.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// redact returns run with its error message redacted for output,
+// or run itself without a redactor or an error.
+func (s *Scheduler) redact(run *Run) *Run {
+	if s.redactor == nil || run.Error == nil {
+		return run
+	}
+	redacted := *run
+	redacted.Error = &redactedError{s.redactor(run.Error.Error()), run.Error}
+	return &redacted
+}
+
+// redactPatterns returns a redactor replacing the matches of patterns
+func redactPatterns(patterns []*regexp.Regexp) func(string) string {
+	return func(str string) string {
+		for _, re := range patterns {
+			str = re.ReplaceAllString(str, "[REDACTED]")
+		}
+		return str
+	}
+}
@@ -0,0 +1,164 @@
+package cronjobs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// Reload syncs the jobs loaded from dirname by ReadFiles with its files:
+// the jobs of new files are registered, the jobs of removed files are
+// unregistered, and the jobs of files whose specs or content changed are
+// replaced. Jobs of unchanged files are left as is, and runs in progress
+// are not interrupted. Each job added or removed is reported to the
+// WithEventObserver observers, with a JobAdded or JobRemoved event.
+// Every file is tried: the ones failing to load keep their previous jobs,
+// and are reported in a FileErrors.
+func (s *Scheduler) Reload(dirname string) error {
+	if err := checkDir(dirname); err != nil {
+		return err
+	}
+
+	var errs FileErrors
+	files := make(map[string]bool)
+	err := filepath.WalkDir(dirname, func(fPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if fPath == dirname {
+				return err
+			}
+			errs = append(errs, fileError(fPath, err))
+			return nil
+		}
+		if d.IsDir() {
+			if fPath != dirname && !s.Recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ok, err := s.matches(d.Name()); !ok {
+			return err
+		}
+		files[fPath] = true
+		if err := s.reloadFile(dirname, fPath); err != nil {
+			errs = append(errs, fileError(fPath, err))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// unregister the jobs of the files of dirname which are gone
+	s.mu.Lock()
+	for _, j := range s.jobs {
+		if j.path != "" && !files[j.path] && s.inDir(dirname, j.path) {
+			s.unregisterFile(j.path)
+		}
+	}
+	s.checkPredecessors()
+	s.queueEvent(Reloaded, "")
+	s.mu.Unlock()
+	s.flushEvents()
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ReloadJob reads again the file of the job with the given name, loaded by
+// ReadFiles, ReadFS or Reload, and replaces its jobs by the ones parsed
+// from it, unless they are unchanged. If the file can't be read or parsed,
+// the jobs are left as is, and the error is returned as a FileError.
+func (s *Scheduler) ReloadJob(name string) error {
+	j, err := s.job(name)
+	if err != nil {
+		return err
+	}
+	if j.source == nil {
+		return fmt.Errorf("job %q was not loaded from a file", name)
+	}
+	jobs, err := s.parseFile(j.source.fsys, j.source.name, j.source.jobName, j.path)
+	if err == nil && len(jobs) == 0 {
+		err = errors.New(`Cron spec ("[...]cron: [spec]") was not found`)
+	}
+	if err != nil {
+		return fileError(j.path, err)
+	}
+	if s.unchanged(j.path, jobs) {
+		return nil
+	}
+	if err := s.register(jobs, j.path); err != nil {
+		return fileError(j.path, err)
+	}
+	return nil
+}
+
+// inDir reports whether ReadFiles(dirname) would load the file fPath
+func (s *Scheduler) inDir(dirname, fPath string) bool {
+	rel, err := filepath.Rel(dirname, fPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	return s.Recursive || !strings.ContainsRune(rel, filepath.Separator)
+}
+
+// reloadFile syncs the jobs loaded from the file fPath, in the job directory
+// dirname, with the file: the jobs are replaced by the ones parsed from it,
+// or unregistered when the file does not exist anymore.
+func (s *Scheduler) reloadFile(dirname, fPath string) error {
+	rel, err := filepath.Rel(dirname, fPath)
+	if err != nil {
+		return err
+	}
+	rel = filepath.ToSlash(rel)
+	if ok, err := s.matches(filepath.Base(fPath)); !ok {
+		return err
+	}
+
+	jobs, err := s.parseFile(dirFS(dirname), rel, s.jobName(rel), fPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		// fPath may have been a directory: remove the jobs of its files too
+		defer s.flushEvents()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, j := range s.jobs {
+			if j.path == fPath || strings.HasPrefix(j.path, fPath+string(filepath.Separator)) {
+				s.unregisterFile(j.path)
+			}
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if s.unchanged(fPath, jobs) {
+		return nil
+	}
+	return s.register(jobs, fPath)
+}
+
+// unchanged reports whether jobs are the same as the ones registered
+// from the file fPath, with the same names, specs and content.
+func (s *Scheduler) unchanged(fPath string, jobs []*job) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, j := range s.jobs {
+		if j.path == fPath {
+			n++
+		}
+	}
+	if n != len(jobs) {
+		return false
+	}
+	for _, j := range jobs {
+		old, ok := s.jobs[j.name]
+		if !ok || old.path != fPath || old.spec != j.spec || old.content != j.content {
+			return false
+		}
+	}
+	return true
+}
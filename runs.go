@@ -0,0 +1,104 @@
+package cronjobs
+
+// Runs returns a channel receiving every Run, in addition to the Logger.
+// Each call returns a new channel, closed when the scheduler stops.
+// The channel must be drained: once its buffer is full, job runs block
+// until there is room for their Run.
+func (s *Scheduler) Runs() <-chan *Run {
+	return s.subscribe(false)
+}
+
+// Errors is like Runs, the channel only receiving the failed runs:
+// not the skipped ones, see Run.Failed.
+func (s *Scheduler) Errors() <-chan *Run {
+	return s.subscribe(true)
+}
+
+// subscriber is a channel returned by Runs, or Errors if failed is set
+type subscriber struct {
+	c      chan *Run
+	failed bool
+}
+
+// subscribe returns a new channel receiving the runs, only the failed ones if failed is set
+func (s *Scheduler) subscribe(failed bool) chan *Run {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := make(chan *Run, cap(s.runs))
+	if s.closed {
+		close(c)
+		return c
+	}
+	s.subscribers = append(s.subscribers, subscriber{c, failed})
+	return c
+}
+
+// FullPolicy defines what happens to a Run when the runs channel is full
+type FullPolicy int
+
+const (
+	// Block makes the job wait for room in the runs channel (default).
+	Block FullPolicy = iota
+	// DropNewest drops the Run, so that the job completes right away.
+	DropNewest
+)
+
+// emit records a Run of j in the stats and the history, reports it to the
+// observers and the callbacks, then sends it on the runs channel.
+func (s *Scheduler) emit(j *job, run *Run) {
+	run.logLevel = j.logLevel
+	// under the same lock, for Snapshot to read them consistently
+	s.history.mu.Lock()
+	s.stats.record(run)
+	s.history.record(run)
+	s.history.mu.Unlock()
+	for _, observe := range s.observers {
+		observe(run)
+	}
+	s.hooks.enqueue(run)
+	if s.fullPolicy == DropNewest {
+		select {
+		case s.runs <- run:
+		default:
+			s.dropped.Add(1)
+		}
+		return
+	}
+	s.runs <- run
+}
+
+// Dropped returns the number of runs dropped because the runs channel
+// was full, see WithFullPolicy.
+func (s *Scheduler) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// dispatch logs each Run, and forwards it to the Runs and Errors subscribers,
+// until the runs channel is closed.
+func (s *Scheduler) dispatch() {
+	for run := range s.runs {
+		s.Logger.LogRun(s.redact(run))
+		s.mu.Lock()
+		subscribers := s.subscribers
+		s.mu.Unlock()
+		for _, sub := range subscribers {
+			if !sub.failed || run.Failed() {
+				sub.c <- run
+			}
+		}
+	}
+	s.closeSubscribers()
+}
+
+// closeSubscribers closes the Runs and Errors channels, and the ones
+// returned afterwards.
+func (s *Scheduler) closeSubscribers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sub := range s.subscribers {
+		close(sub.c)
+	}
+	s.subscribers = nil
+	s.closed = true
+	close(s.drained)
+}
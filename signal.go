@@ -0,0 +1,36 @@
+package cronjobs
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// RunUntilSignal starts s, and runs it until one of signals is received,
+// SIGINT or SIGTERM when none is given, or until ctx is done.
+// It then stops s, letting in-flight jobs finish for up to timeout
+// (no limit if 0): it returns context.DeadlineExceeded if some were still
+// running, for the caller to exit anyway.
+func RunUntilSignal(ctx context.Context, s *Scheduler, timeout time.Duration, signals ...os.Signal) error {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ctx, stop := signal.NotifyContext(ctx, signals...)
+	defer stop()
+
+	// the scheduler is stopped below, with a bounded wait
+	if err := s.Start(context.Background()); err != nil {
+		return err
+	}
+	<-ctx.Done()
+
+	stopCtx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		stopCtx, cancel = context.WithTimeout(stopCtx, timeout)
+		defer cancel()
+	}
+	return s.StopWait(stopCtx)
+}
@@ -0,0 +1,101 @@
+package cronjobs
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// slots limits the number of jobs executing at the same time.
+// The jobs waiting for a slot get it by priority, and in arrival order
+// for the same priority, or only in arrival order when fifo is set.
+type slots struct {
+	mu      sync.Mutex
+	free    int
+	fifo    bool
+	seq     uint64
+	waiters waiters
+}
+
+func newSlots(n int) *slots {
+	return &slots{free: n}
+}
+
+// waiter is a job waiting for a slot, ready being closed once it has one
+type waiter struct {
+	priority int
+	seq      uint64
+	index    int
+	ready    chan struct{}
+}
+
+// waiters is a heap of waiters, the next one to get a slot first
+type waiters []*waiter
+
+func (w waiters) Len() int { return len(w) }
+func (w waiters) Less(a, b int) bool {
+	if w[a].priority != w[b].priority {
+		return w[a].priority > w[b].priority
+	}
+	return w[a].seq < w[b].seq
+}
+func (w waiters) Swap(a, b int) {
+	w[a], w[b] = w[b], w[a]
+	w[a].index, w[b].index = a, b
+}
+func (w *waiters) Push(x interface{}) {
+	wt := x.(*waiter)
+	wt.index = len(*w)
+	*w = append(*w, wt)
+}
+func (w *waiters) Pop() interface{} {
+	old := *w
+	wt := old[len(old)-1]
+	*w = old[:len(old)-1]
+	return wt
+}
+
+// acquire waits for a slot, until ctx is done
+func (sl *slots) acquire(ctx context.Context, priority int) error {
+	sl.mu.Lock()
+	if sl.fifo {
+		priority = 0
+	}
+	if sl.free > 0 && len(sl.waiters) == 0 {
+		sl.free--
+		sl.mu.Unlock()
+		return nil
+	}
+	sl.seq++
+	w := &waiter{priority: priority, seq: sl.seq, ready: make(chan struct{})}
+	heap.Push(&sl.waiters, w)
+	sl.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		sl.mu.Lock()
+		select {
+		case <-w.ready:
+			// got the slot meanwhile: hand it over
+			sl.mu.Unlock()
+			sl.release()
+		default:
+			heap.Remove(&sl.waiters, w.index)
+			sl.mu.Unlock()
+		}
+		return ctx.Err()
+	}
+}
+
+// release frees a slot, given to the next waiter if any
+func (sl *slots) release() {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	if len(sl.waiters) == 0 {
+		sl.free++
+		return
+	}
+	close(heap.Pop(&sl.waiters).(*waiter).ready)
+}
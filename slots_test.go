@@ -0,0 +1,95 @@
+package cronjobs
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// waiting waits until n jobs wait for a slot of sl
+func waiting(t *testing.T, sl *slots, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		sl.mu.Lock()
+		l := len(sl.waiters)
+		sl.mu.Unlock()
+		if l == n {
+			return
+		}
+	}
+	t.Fatalf("%d jobs not waiting for a slot", n)
+}
+
+func TestSlotsOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		fifo       bool
+		priorities []int // of the waiters, in arrival order
+		want       []int // the waiters, in the order they get a slot
+	}{
+		{"arrival order", false, []int{0, 0, 0}, []int{0, 1, 2}},
+		{"priority first", false, []int{-1, 0, 1, 0}, []int{2, 1, 3, 0}},
+		{"fifo", true, []int{-1, 0, 1, 0}, []int{0, 1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sl := newSlots(1)
+			sl.fifo = tt.fifo
+			if err := sl.acquire(context.Background(), 0); err != nil {
+				t.Fatal(err)
+			}
+			got := make(chan int, len(tt.priorities))
+			for i, p := range tt.priorities {
+				go func(i, p int) {
+					if err := sl.acquire(context.Background(), p); err == nil {
+						got <- i
+					}
+				}(i, p)
+				waiting(t, sl, i+1)
+			}
+			var order []int
+			for range tt.priorities {
+				sl.release()
+				order = append(order, <-got)
+			}
+			if !reflect.DeepEqual(order, tt.want) {
+				t.Errorf("slots given to %v, want %v", order, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlotsCancel(t *testing.T) {
+	sl := newSlots(1)
+	if err := sl.acquire(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error)
+	go func() { cancelled <- sl.acquire(ctx, 1) }()
+	waiting(t, sl, 1)
+	got := make(chan struct{})
+	go func() {
+		if sl.acquire(context.Background(), 0) == nil {
+			close(got)
+		}
+	}()
+	waiting(t, sl, 2)
+
+	cancel()
+	if err := <-cancelled; err != context.Canceled {
+		t.Fatalf("cancelled acquire = %v, want context.Canceled", err)
+	}
+	waiting(t, sl, 1)
+	sl.release()
+	select {
+	case <-got:
+	case <-time.After(time.Second):
+		t.Fatal("slot not given to the remaining waiter")
+	}
+	sl.release()
+	if sl.free != 1 {
+		t.Errorf("%d free slots, want 1", sl.free)
+	}
+}
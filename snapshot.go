@@ -0,0 +1,61 @@
+package cronjobs
+
+import (
+	"sort"
+	"time"
+)
+
+// SchedulerSnapshot is the state of a scheduler and its jobs, at Time
+type SchedulerSnapshot struct {
+	Time          time.Time
+	Started       bool
+	Stopped       bool
+	Paused        bool
+	Running       int // jobs executing, see Running
+	TotalRuns     uint64
+	TotalFailures uint64
+	Jobs          []JobSnapshot // sorted by name
+}
+
+// JobSnapshot is the state of a job in a SchedulerSnapshot
+type JobSnapshot struct {
+	JobInfo
+	Runs     uint64
+	Failures uint64
+	LastRun  *Run // nil if the job has not run yet
+}
+
+// Snapshot returns the state of the scheduler and its jobs, read at once
+// so that it is consistent: the jobs, their last runs and their counters.
+// The snapshot is a copy, sharing nothing with the scheduler.
+func (s *Scheduler) Snapshot() SchedulerSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history.mu.Lock()
+	defer s.history.mu.Unlock()
+
+	snap := SchedulerSnapshot{
+		Time:          s.clock.Now(),
+		Started:       s.started,
+		Stopped:       s.stopped,
+		Paused:        s.IsPaused(),
+		Running:       s.Running(),
+		TotalRuns:     s.TotalRuns(),
+		TotalFailures: s.TotalFailures(),
+		Jobs:          make([]JobSnapshot, 0, len(s.jobs)),
+	}
+	for _, j := range s.jobs {
+		js := JobSnapshot{JobInfo: s.info(j)}
+		js.Runs, js.Failures = s.Stats(j.name)
+		if run, ok := s.history.last(j.name); ok {
+			if run.RowsAffected != nil {
+				rows := *run.RowsAffected
+				run.RowsAffected = &rows
+			}
+			js.LastRun = &run
+		}
+		snap.Jobs = append(snap.Jobs, js)
+	}
+	sort.Slice(snap.Jobs, func(a, b int) bool { return snap.Jobs[a].Name < snap.Jobs[b].Name })
+	return snap
+}
@@ -0,0 +1,83 @@
+package cronjobs
+
+import "strings"
+
+// splitStatements splits sql on the ";" ending its statements, leaving out
+// the empty ones, or the ones only made of comments. It is not a full SQL
+// parser, but ignores the ";" of quoted strings and identifiers (”, "",
+// “), of comments (--, /* */), and of PostgreSQL dollar-quoted strings
+// ($$ $$, $tag$ $tag$).
+func splitStatements(sql string) []string {
+	var statements []string
+	start := 0
+	code := false // whether the current statement has something else than comments
+	add := func(end int) {
+		if code {
+			statements = append(statements, strings.TrimSpace(sql[start:end]))
+		}
+		start = end + 1
+		code = false
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == ';':
+			add(i)
+		case c == '\'' || c == '"' || c == '`':
+			code = true
+			i = skipQuoted(sql, i, c)
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			i = skipUntil(sql, i, "\n")
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			i = skipUntil(sql, i+2, "*/") + 1
+		case c == '$':
+			code = true
+			if tag := dollarTag(sql[i:]); tag != "" {
+				i = skipUntil(sql, i+len(tag), tag) + len(tag) - 1
+			}
+		case c != ' ' && c != '\t' && c != '\n' && c != '\r':
+			code = true
+		}
+	}
+	add(len(sql))
+	return statements
+}
+
+// skipQuoted returns the index of the quote closing the string opened
+// by quote at i, a doubled quote being an escaped one.
+func skipQuoted(sql string, i int, quote byte) int {
+	for i++; i < len(sql); i++ {
+		if sql[i] == quote {
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return len(sql)
+}
+
+// skipUntil returns the index of the first char of end after i,
+// or the end of sql.
+func skipUntil(sql string, i int, end string) int {
+	if j := strings.Index(sql[i:], end); j >= 0 {
+		return i + j
+	}
+	return len(sql)
+}
+
+// dollarTag returns the dollar quote, like $$ or $tag$, starting sql
+func dollarTag(sql string) string {
+	for i := 1; i < len(sql); i++ {
+		c := sql[i]
+		if c == '$' {
+			return sql[:i+1]
+		}
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 1 && c >= '0' && c <= '9') {
+			return ""
+		}
+	}
+	return ""
+}
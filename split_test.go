@@ -0,0 +1,38 @@
+package cronjobs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{"single", "SELECT 1", []string{"SELECT 1"}},
+		{"several", "SELECT 1; SELECT 2;\n", []string{"SELECT 1", "SELECT 2"}},
+		{"empty statements", " ;;\n; ", nil},
+		{"only comments", "-- nothing; here\n/* nor; there */;", nil},
+		{"leading comment", "-- header\nSELECT 1;", []string{"-- header\nSELECT 1"}},
+		{"single quotes", "SELECT 'a;b'; SELECT 2", []string{"SELECT 'a;b'", "SELECT 2"}},
+		{"escaped quote", "SELECT 'it''s;'; SELECT 2", []string{"SELECT 'it''s;'", "SELECT 2"}},
+		{"double quotes", `SELECT "a;b" FROM t; SELECT 2`, []string{`SELECT "a;b" FROM t`, "SELECT 2"}},
+		{"backquotes", "SELECT `a;b` FROM t; SELECT 2", []string{"SELECT `a;b` FROM t", "SELECT 2"}},
+		{"line comment", "SELECT 1 -- one; two\n; SELECT 2", []string{"SELECT 1 -- one; two", "SELECT 2"}},
+		{"block comment", "SELECT /* ; */ 1; SELECT 2", []string{"SELECT /* ; */ 1", "SELECT 2"}},
+		{"dollar quotes", "DO $$ BEGIN x; END $$; SELECT 2", []string{"DO $$ BEGIN x; END $$", "SELECT 2"}},
+		{"dollar tags", "DO $fn$ a; $$ b; $fn$; SELECT 2", []string{"DO $fn$ a; $$ b; $fn$", "SELECT 2"}},
+		{"parameters", "SELECT $1; SELECT $2", []string{"SELECT $1", "SELECT $2"}},
+		{"unterminated quote", "SELECT 'a; b", []string{"SELECT 'a; b"}},
+		{"unterminated comment", "SELECT 1; /* a; b", []string{"SELECT 1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.sql); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements(%q) = %q, want %q", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
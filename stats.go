@@ -0,0 +1,60 @@
+package cronjobs
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// stats counts the runs and failures (see Run.Failed), in total and per job
+type stats struct {
+	runs     atomic.Uint64
+	failures atomic.Uint64
+	jobs     sync.Map // job name -> *jobStats
+}
+
+// jobStats counts the runs and failures of a job
+type jobStats struct {
+	runs     atomic.Uint64
+	failures atomic.Uint64
+}
+
+// record counts run
+func (st *stats) record(run *Run) {
+	v, ok := st.jobs.Load(run.Name)
+	if !ok {
+		v, _ = st.jobs.LoadOrStore(run.Name, &jobStats{})
+	}
+	js := v.(*jobStats)
+	st.runs.Add(1)
+	js.runs.Add(1)
+	if run.Failed() {
+		st.failures.Add(1)
+		js.failures.Add(1)
+	}
+}
+
+// TotalRuns returns the number of runs of all jobs
+func (s *Scheduler) TotalRuns() uint64 {
+	return s.stats.runs.Load()
+}
+
+// TotalFailures returns the number of failed runs of all jobs
+func (s *Scheduler) TotalFailures() uint64 {
+	return s.stats.failures.Load()
+}
+
+// Stats returns the number of runs, and of failed runs, of the job with the given name
+func (s *Scheduler) Stats(name string) (runs, failures uint64) {
+	v, ok := s.stats.jobs.Load(name)
+	if !ok {
+		return 0, 0
+	}
+	js := v.(*jobStats)
+	return js.runs.Load(), js.failures.Load()
+}
+
+// Running returns the number of jobs executing right now, not counting
+// the ones waiting for a WithMaxConcurrency slot, or between retries.
+func (s *Scheduler) Running() int {
+	return int(s.executing.Load())
+}
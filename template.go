@@ -0,0 +1,48 @@
+package cronjobs
+
+import (
+	"os"
+	"strings"
+	"text/template"
+)
+
+// templating renders job bodies as text/template templates
+type templating struct {
+	data   func() interface{}
+	static bool // data does not change: templates are rendered once, at load time
+}
+
+// templateFuncs are the functions available to job templates
+var templateFuncs = template.FuncMap{
+	"env": os.Getenv,
+}
+
+// parseTemplate parses the body of a job as a template, without its spec lines,
+// so that their content is not interpreted. With static data, body is rendered
+// right away, and no template is returned.
+func (s *Scheduler) parseTemplate(name, content string, specs []specLine) (string, *template.Template, error) {
+	lines := strings.Split(content, "\n")
+	for _, spec := range specs {
+		for _, l := range spec.lines() {
+			lines[l-1] = ""
+		}
+	}
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(strings.Join(lines, "\n"))
+	if err != nil {
+		return "", nil, err
+	}
+	if !s.templating.static {
+		return "", tmpl, nil
+	}
+	body, err := render(tmpl, s.templating.data())
+	return body, nil, err
+}
+
+// render executes tmpl with data
+func render(tmpl *template.Template, data interface{}) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
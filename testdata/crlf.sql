@@ -0,0 +1,2 @@
+-- cron: @daily desc="saved on Windows"
+DELETE FROM sessions WHERE expired;
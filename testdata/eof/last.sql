@@ -0,0 +1,2 @@
+DELETE FROM sessions WHERE expired;
+-- cron: @daily
\ No newline at end of file
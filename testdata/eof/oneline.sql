@@ -0,0 +1 @@
+-- cron: @hourly
\ No newline at end of file
@@ -0,0 +1,129 @@
+package cronjobs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long Watch waits for changes to settle
+// before reloading the changed files.
+const watchDebounce = 500 * time.Millisecond
+
+// Watch keeps the jobs loaded from dirname by ReadFiles in sync with
+// the files, until ctx is done: new files are loaded, modified files are
+// reloaded, and the jobs of removed files are unregistered.
+// The changes are applied once no event was seen for watchDebounce, so that
+// an editor writing a file several times triggers a single reload.
+// A file failing to reload keeps its previous jobs, and the error
+// is sent on errs, when not nil.
+// Watch blocks, and returns nil when ctx is done or the error preventing
+// to watch dirname.
+func (s *Scheduler) Watch(ctx context.Context, dirname string, errs chan<- error) error {
+	if err := checkDir(dirname); err != nil {
+		return err
+	}
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	if err := s.watchDir(w, dirname); err != nil {
+		return err
+	}
+
+	report := func(err error) {
+		if errs == nil {
+			return
+		}
+		select {
+		case errs <- err:
+		case <-ctx.Done():
+		}
+	}
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			pending[ev.Name] = true
+			timer.Reset(watchDebounce)
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			report(err)
+		case <-timer.C:
+			for fPath := range pending {
+				if err := s.reloadPath(w, dirname, fPath); err != nil {
+					report(err)
+				}
+			}
+			pending = make(map[string]bool)
+			s.notify(Reloaded, "")
+		}
+	}
+}
+
+// watchDir adds dir to w, and its subdirectories when Recursive is set
+func (s *Scheduler) watchDir(w *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != dir && !s.Recursive {
+			return filepath.SkipDir
+		}
+		return w.Add(p)
+	})
+}
+
+// reloadPath syncs the jobs with the changed path fPath of the job directory dirname.
+// A new subdirectory is watched and its files are loaded, when Recursive is set.
+func (s *Scheduler) reloadPath(w *fsnotify.Watcher, dirname, fPath string) error {
+	info, err := os.Stat(fPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fileError(fPath, err)
+	}
+	if err == nil && info.IsDir() {
+		if !s.Recursive {
+			return nil
+		}
+		if err := s.watchDir(w, fPath); err != nil {
+			return fileError(fPath, err)
+		}
+		var errs FileErrors
+		filepath.WalkDir(fPath, func(p string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				if err := s.reloadFile(dirname, p); err != nil {
+					errs = append(errs, fileError(p, err))
+				}
+			}
+			return nil
+		})
+		if len(errs) > 0 {
+			return errs
+		}
+		return nil
+	}
+	if err := s.reloadFile(dirname, fPath); err != nil {
+		return fileError(fPath, err)
+	}
+	return nil
+}
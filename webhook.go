@@ -0,0 +1,96 @@
+package cronjobs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// webhook posts the failed runs to a URL
+type webhook struct {
+	url      string
+	client   *http.Client
+	attempts int
+	backoff  time.Duration
+}
+
+// WebhookOption configures WithWebhook
+type WebhookOption func(*webhook)
+
+// WebhookTimeout sets the timeout of each request, 10s by default
+func WebhookTimeout(d time.Duration) WebhookOption {
+	return func(w *webhook) {
+		w.client = &http.Client{Timeout: d}
+	}
+}
+
+// WebhookAttempts sets the number of attempts to post a run, 3 by default,
+// waiting backoff between them, doubled each time.
+func WebhookAttempts(attempts int, backoff time.Duration) WebhookOption {
+	return func(w *webhook) {
+		w.attempts, w.backoff = attempts, backoff
+	}
+}
+
+// WithWebhook posts each failed run to url, as a JSON object like the
+// last_run of the Handler. The skipped runs are not posted, and neither are
+// the failed attempts retried by WithRetry, only the last one:
+//
+//	{"id": 42, "name": "cleanup", "error": "...", "started_at": "...", "duration": 1500000000}
+//
+// The runs are posted by an OnFailure callback, so that a slow endpoint
+// doesn't hold up the jobs. A request failing, or answered with an error
+// status, is retried, then logged.
+func WithWebhook(url string, opts ...WebhookOption) Option {
+	w := &webhook{
+		url:      url,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		attempts: 3,
+		backoff:  time.Second,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return func(s *Scheduler) {
+		s.OnFailure(func(run *Run) {
+			if !run.final {
+				return
+			}
+			if err := w.post(s.redact(run)); err != nil {
+				s.warn("posting run to webhook", "job", run.Name, "error", err)
+			}
+		})
+	}
+}
+
+// post posts run, retrying on failure
+func (w *webhook) post(run *Run) error {
+	body, err := json.Marshal(newRunStatus(*run))
+	if err != nil {
+		return err
+	}
+	backoff := w.backoff
+	for attempt := 1; ; attempt++ {
+		err = w.send(body)
+		if err == nil || attempt >= w.attempts {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// send posts body once
+func (w *webhook) send(body []byte) error {
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: %s", w.url, resp.Status)
+	}
+	return nil
+}